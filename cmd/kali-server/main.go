@@ -8,9 +8,13 @@ import (
 	"os"
 	"time"
 
+	"github.com/ba0f3/MCP-Kali-Server/pkg/credentials"
 	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
 	"github.com/ba0f3/MCP-Kali-Server/pkg/handlers"
 	"github.com/ba0f3/MCP-Kali-Server/pkg/middleware"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/prompts"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/runs"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/tools"
 	"github.com/gin-gonic/gin"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -19,11 +23,60 @@ func main() {
 	// Define command-line flags
 	timeout := flag.Int("timeout", 900, "Command execution timeout in seconds")
 	port := flag.Int("port", 5000, "Port to listen on")
+	maxConcurrent := flag.Int("max-concurrent", executor.DefaultMaxConcurrent, "Maximum number of commands running at once (0 = unlimited)")
+	maxPerTool := flag.Int("max-per-tool", 0, "Maximum number of concurrent runs of a single tool (0 = unlimited)")
+	toolLimits := flag.String("tool-limits", "", "Per-tool concurrency limits, e.g. nmap=2,hydra=1")
+	maxQueue := flag.Int("max-queue", executor.DefaultMaxQueue, "Maximum number of commands waiting for a free slot (0 = unlimited)")
+	maxOutput := flag.Int("max-output", executor.DefaultMaxOutputSize, "Maximum output in bytes kept in memory per stream, the rest is written to -artifact-dir (0 = unlimited)")
+	artifactDir := flag.String("artifact-dir", executor.ArtifactDir, "Directory for per-run artifacts such as spilled output")
+	maxRuns := flag.Int("max-runs", runs.DefaultMaxRuns, "Maximum number of recorded runs kept in -artifact-dir, the oldest are removed (0 = unlimited)")
+	maxRunAge := flag.Duration("max-run-age", 0, "Remove recorded runs older than this, e.g. 720h (0 = keep)")
+	resourceLimits := flag.String("resource-limits", "", "JSON file with per-tool resource limits (memory, CPU time, nice, processes, open files, wall clock)")
+	cgroupRoot := flag.String("cgroup-root", "", "Delegated cgroup v2 directory used to enforce memory and process limits")
+	confirmRisk := flag.String("confirm-risk", "", "Require confirmation to run tools of this risk level or higher: low, medium, high or critical (default: never)")
+	promptDir := flag.String("prompt-dir", "", "Directory with additional MCP prompts (*.json playbooks), overriding built-in prompts of the same name")
 	flag.Parse()
 
 	// Set the global command timeout
 	executor.SetGlobalTimeout(time.Duration(*timeout) * time.Second)
 
+	// Configure output capture
+	executor.SetMaxOutputSize(*maxOutput)
+	executor.SetArtifactDir(*artifactDir)
+	runs.SetRetention(*maxRuns, *maxRunAge)
+
+	// Configure resource limits
+	if *resourceLimits != "" {
+		if err := executor.LoadResourceLimits(*resourceLimits); err != nil {
+			log.Fatalf("Invalid -resource-limits: %v", err)
+		}
+	}
+	executor.SetCgroupRoot(*cgroupRoot)
+
+	// Configure the confirmation policy
+	if *confirmRisk != "" {
+		risk, err := tools.ParseRisk(*confirmRisk)
+		if err != nil {
+			log.Fatalf("Invalid -confirm-risk: %v", err)
+		}
+		tools.SetConfirmRisk(risk)
+	}
+
+	// Configure prompts
+	prompts.SetDir(*promptDir)
+
+	// Configure the execution limiter
+	limits, err := executor.ParseToolLimits(*toolLimits)
+	if err != nil {
+		log.Fatalf("Invalid -tool-limits: %v", err)
+	}
+	executor.ConfigureLimiter(executor.LimiterConfig{
+		MaxConcurrent: *maxConcurrent,
+		MaxPerTool:    *maxPerTool,
+		ToolLimits:    limits,
+		MaxQueue:      *maxQueue,
+	})
+
 	// Determine mode of the server from environment variable or configuration
 	mode := os.Getenv("SERVER_MODE")
 	if mode == "" {
@@ -34,14 +87,20 @@ func main() {
 	log.Println("=== MCP-Kali-Server Configuration ===")
 	log.Printf("Server Mode: %s", mode)
 	log.Printf("Command Timeout: %d seconds", *timeout)
+	log.Printf("Max Concurrent Commands: %d (queue: %d)", *maxConcurrent, *maxQueue)
+	log.Printf("Artifact Directory: %s", *artifactDir)
+	log.Printf("Run Retention: %d runs, max age %v", *maxRuns, *maxRunAge)
+	if *promptDir != "" {
+		log.Printf("Prompt Directory: %s", *promptDir)
+	}
+	if *confirmRisk != "" {
+		log.Printf("Confirmation Required: %s risk tools and above", *confirmRisk)
+	}
 	log.Printf("Port: %d", *port)
 
 	if mode == "mcp" {
-		// Create the MCP server
-		server := mcp.NewServer(&mcp.Implementation{Name: "Kali Server", Version: "v1.0.0"}, nil)
-
-		// Define tool handlers here
-		// Example: mcp.AddTool(server, mcp.Tool{Name: "nmap", Description: "Network exploration tool"}, nmapHandler)
+		// Create the MCP server with all Kali tools registered
+		server := handlers.InitializeServer()
 
 		// Create MCP streamable HTTP handler
 		handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil)
@@ -52,6 +111,15 @@ func main() {
 			log.Fatalf("Could not start MCP server: %v", err)
 		}
 	} else {
+		// Load the runs recorded earlier, so that the retention limits cover
+		// them, and the credentials found earlier
+		if err := runs.DefaultStore.Load(); err != nil {
+			log.Printf("Failed to load runs: %v", err)
+		}
+		if err := credentials.DefaultStore.Load(); err != nil {
+			log.Printf("Failed to load credentials: %v", err)
+		}
+
 		// Setup Gin router
 		gin.SetMode(gin.ReleaseMode)
 		r := gin.Default()
@@ -68,19 +136,28 @@ func main() {
 		log.Println("=====================================")
 
 		// Setup routes
-		r.POST("/api/command", handlers.GenericCommandHandler)
-		r.POST("/api/stream/command", handlers.StreamCommandHandler)
-		r.POST("/api/tools/nmap", handlers.NmapHandler)
-		r.POST("/api/tools/gobuster", handlers.GobusterHandler)
-		r.POST("/api/tools/dirb", handlers.DirbHandler)
-		r.POST("/api/tools/nikto", handlers.NiktoHandler)
-		r.POST("/api/tools/sqlmap", handlers.SqlmapHandler)
-		r.POST("/api/tools/metasploit", handlers.MetasploitHandler)
-		r.POST("/api/tools/hydra", handlers.HydraHandler)
-		r.POST("/api/tools/john", handlers.JohnHandler)
-		r.POST("/api/tools/wpscan", handlers.WpscanHandler)
-		r.POST("/api/tools/enum4linux", handlers.Enum4linuxHandler)
-		r.POST("/api/tools/sublist3r", handlers.Sublist3rHandler)
+		r.POST("/api/command", handlers.ConfirmTool("execute_command"), handlers.GenericCommandHandler)
+		r.POST("/api/stream/command", handlers.ConfirmTool("execute_command"), handlers.StreamCommandHandler)
+		r.POST("/api/tools/nmap", handlers.ConfirmTool("nmap_scan"), handlers.NmapHandler)
+		r.POST("/api/tools/gobuster", handlers.ConfirmTool("gobuster_scan"), handlers.GobusterHandler)
+		r.POST("/api/tools/dirb", handlers.ConfirmTool("dirb_scan"), handlers.DirbHandler)
+		r.POST("/api/tools/nikto", handlers.ConfirmTool("nikto_scan"), handlers.NiktoHandler)
+		r.POST("/api/tools/sqlmap", handlers.ConfirmTool("sqlmap_scan"), handlers.SqlmapHandler)
+		r.POST("/api/tools/sqlmap/resume", handlers.ConfirmTool("sqlmap_resume"), handlers.ResumeSqlmapHandler)
+		r.POST("/api/tools/metasploit", handlers.ConfirmTool("metasploit_run"), handlers.MetasploitHandler)
+		r.POST("/api/tools/hydra", handlers.ConfirmTool("hydra_attack"), handlers.HydraHandler)
+		r.POST("/api/tools/john", handlers.ConfirmTool("john_crack"), handlers.JohnHandler)
+		r.POST("/api/tools/wpscan", handlers.ConfirmTool("wpscan_analyze"), handlers.WpscanHandler)
+		r.POST("/api/tools/enum4linux", handlers.ConfirmTool("enum4linux_scan"), handlers.Enum4linuxHandler)
+		r.POST("/api/tools/sublist3r", handlers.ConfirmTool("sublist3r_scan"), handlers.Sublist3rHandler)
+		r.GET("/api/tools", handlers.ListToolsHandler)
+		r.POST("/api/jobs", handlers.StartJobHandler)
+		r.GET("/api/jobs", handlers.ListJobsHandler)
+		r.GET("/api/jobs/:id", handlers.GetJobHandler)
+		r.GET("/api/jobs/:id/output", handlers.GetJobOutputHandler)
+		r.POST("/api/jobs/:id/cancel", handlers.CancelJobHandler)
+		r.GET("/api/credentials", handlers.ListCredentialsHandler)
+		r.GET("/api/credentials/export", handlers.ExportCredentialsHandler)
 		r.GET("/health", handlers.HealthCheckHandler)
 
 		// Start the Gin server
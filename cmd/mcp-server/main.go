@@ -12,7 +12,10 @@ import (
 	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
 	"github.com/ba0f3/MCP-Kali-Server/pkg/handlers"
 	"github.com/ba0f3/MCP-Kali-Server/pkg/middleware"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/prompts"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/runs"
 	"github.com/ba0f3/MCP-Kali-Server/pkg/service"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/tools"
 	"github.com/gin-gonic/gin"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -67,6 +70,18 @@ func main() {
 		uninstallService = flag.Bool("uninstall-service", false, "Uninstall the system service")
 		serviceName = flag.String("service-name", "mcp-kali-server", "Name of the service")
 		servicePort = flag.String("service-port", ":8080", "Port for the service to listen on (used with -install-service)")
+		maxConcurrent = flag.Int("max-concurrent", executor.DefaultMaxConcurrent, "Maximum number of commands running at once (0 = unlimited)")
+		maxPerTool = flag.Int("max-per-tool", 0, "Maximum number of concurrent runs of a single tool (0 = unlimited)")
+		toolLimits = flag.String("tool-limits", "", "Per-tool concurrency limits, e.g. nmap=2,hydra=1")
+		maxQueue = flag.Int("max-queue", executor.DefaultMaxQueue, "Maximum number of commands waiting for a free slot (0 = unlimited)")
+		maxOutput = flag.Int("max-output", executor.DefaultMaxOutputSize, "Maximum output in bytes kept in memory per stream, the rest is written to -artifact-dir (0 = unlimited)")
+		artifactDir = flag.String("artifact-dir", executor.ArtifactDir, "Directory for per-run artifacts such as spilled output")
+		maxRuns = flag.Int("max-runs", runs.DefaultMaxRuns, "Maximum number of recorded runs kept in -artifact-dir, the oldest are removed (0 = unlimited)")
+		maxRunAge = flag.Duration("max-run-age", 0, "Remove recorded runs older than this, e.g. 720h (0 = keep)")
+		resourceLimits = flag.String("resource-limits", "", "JSON file with per-tool resource limits (memory, CPU time, nice, processes, open files, wall clock)")
+		cgroupRoot = flag.String("cgroup-root", "", "Delegated cgroup v2 directory used to enforce memory and process limits")
+		confirmRisk = flag.String("confirm-risk", "", "Require confirmation to run tools of this risk level or higher: low, medium, high or critical (default: never)")
+		promptDir = flag.String("prompt-dir", "", "Directory with additional MCP prompts (*.json playbooks), overriding built-in prompts of the same name")
 	)
 	flag.Parse()
 
@@ -88,10 +103,56 @@ func main() {
 	// Set the global command timeout
 	executor.SetGlobalTimeout(time.Duration(*timeout) * time.Second)
 
+	// Configure output capture
+	executor.SetMaxOutputSize(*maxOutput)
+	executor.SetArtifactDir(*artifactDir)
+	runs.SetRetention(*maxRuns, *maxRunAge)
+
+	// Configure resource limits
+	if *resourceLimits != "" {
+		if err := executor.LoadResourceLimits(*resourceLimits); err != nil {
+			log.Fatalf("Invalid -resource-limits: %v", err)
+		}
+	}
+	executor.SetCgroupRoot(*cgroupRoot)
+
+	// Configure the confirmation policy
+	if *confirmRisk != "" {
+		risk, err := tools.ParseRisk(*confirmRisk)
+		if err != nil {
+			log.Fatalf("Invalid -confirm-risk: %v", err)
+		}
+		tools.SetConfirmRisk(risk)
+	}
+
+	// Configure prompts
+	prompts.SetDir(*promptDir)
+
+	// Configure the execution limiter
+	limits, err := executor.ParseToolLimits(*toolLimits)
+	if err != nil {
+		log.Fatalf("Invalid -tool-limits: %v", err)
+	}
+	executor.ConfigureLimiter(executor.LimiterConfig{
+		MaxConcurrent: *maxConcurrent,
+		MaxPerTool:    *maxPerTool,
+		ToolLimits:    limits,
+		MaxQueue:      *maxQueue,
+	})
+
 	// Print server configuration
 	log.Println("=== MCP-Kali-Server Configuration ===")
 	log.Println("Server Mode: MCP")
 	log.Printf("Command Timeout: %d seconds", *timeout)
+	log.Printf("Max Concurrent Commands: %d (queue: %d)", *maxConcurrent, *maxQueue)
+	log.Printf("Artifact Directory: %s", *artifactDir)
+	log.Printf("Run Retention: %d runs, max age %v", *maxRuns, *maxRunAge)
+	if *promptDir != "" {
+		log.Printf("Prompt Directory: %s", *promptDir)
+	}
+	if *confirmRisk != "" {
+		log.Printf("Confirmation Required: %s risk tools and above", *confirmRisk)
+	}
 	log.Printf("Debug Mode: %v", *debug)
 	if *httpAddr != "" {
 		log.Printf("HTTP Address: %s", *httpAddr)
@@ -99,96 +160,15 @@ func main() {
 		log.Println("Transport: stdio")
 	}
 	log.Println("Available Tools:")
-	log.Println("  - nmap_scan")
-	log.Println("  - gobuster_scan")
-	log.Println("  - dirb_scan")
-	log.Println("  - nikto_scan")
-	log.Println("  - sqlmap_scan")
-	log.Println("  - hydra_attack")
-	log.Println("  - john_crack")
-	log.Println("  - wpscan_analyze")
-	log.Println("  - enum4linux_scan")
-	log.Println("  - ping")
-	log.Println("  - nuclei_scan")
-	log.Println("  - execute_command")
+	for _, def := range tools.Definitions() {
+		log.Printf("  - %s (%s risk)", def.Name, def.Risk)
+	}
+	log.Println("  - job_start, job_status, job_output, job_cancel, job_list")
+	log.Println("  - credentials_list, credentials_export")
 	log.Println("=====================================")
 
 	// Initialize MCP server
-	server := mcp.NewServer(&mcp.Implementation{Name: "kali-tools"}, nil)
-
-	// Add Nmap tool
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "nmap_scan",
-		Description: "Execute an Nmap scan against a target",
-	}, handlers.NmapScanHandler)
-
-	// Add Gobuster tool
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "gobuster_scan",
-		Description: "Execute Gobuster to find directories, DNS subdomains, or virtual hosts",
-	}, handlers.GobusterScanHandler)
-
-	// Add Dirb tool
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "dirb_scan",
-		Description: "Execute Dirb web content scanner",
-	}, handlers.DirbScanHandler)
-
-	// Add Nikto tool
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "nikto_scan",
-		Description: "Execute Nikto web server scanner",
-	}, handlers.NiktoScanHandler)
-
-	// Add SQLmap tool
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "sqlmap_scan",
-		Description: "Execute SQLmap SQL injection scanner",
-	}, handlers.SqlmapScanHandler)
-
-	// Add Hydra tool
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "hydra_attack",
-		Description: "Execute Hydra password cracking tool",
-	}, handlers.HydraAttackHandler)
-
-	// Add John the Ripper tool
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "john_crack",
-		Description: "Execute John the Ripper password cracker",
-	}, handlers.JohnCrackHandler)
-
-	// Add WPScan tool
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "wpscan_analyze",
-		Description: "Execute WPScan WordPress vulnerability scanner",
-	}, handlers.WpscanAnalyzeHandler)
-
-	// Add Enum4linux tool
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "enum4linux_scan",
-		Description: "Execute Enum4linux Windows/Samba enumeration tool",
-	}, handlers.Enum4linuxScanHandler)
-
-	// Add Ping tool
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "ping",
-		Description: "Execute ping to test network connectivity",
-	}, handlers.PingHandler)
-
-	// Add Nuclei tool
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "nuclei_scan",
-		Description: "Execute Nuclei template-based vulnerability scanner",
-	}, handlers.NucleiScanHandler)
-
-	// Add generic command execution tool
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "execute_command",
-		Description: "Execute an arbitrary command on the Kali server",
-	}, handlers.ExecuteCommandHandler)
-
-
+	server := handlers.InitializeServer()
 
 	if *httpAddr != "" {
 		// Set Gin to release mode for cleaner logs
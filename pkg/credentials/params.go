@@ -0,0 +1,16 @@
+package credentials
+
+// ListParams represents parameters for listing credentials
+type ListParams struct {
+	Host    string `json:"host,omitempty" jsonschema:"Only credentials of this host" example:"192.168.1.10"`
+	Service string `json:"service,omitempty" jsonschema:"Only credentials of this service" example:"ssh"`
+	Source  string `json:"source,omitempty" jsonschema:"Only credentials found by this tool" example:"hydra"`
+}
+
+// ExportParams represents parameters for exporting credentials
+type ExportParams struct {
+	Host    string `json:"host,omitempty" jsonschema:"Only credentials of this host" example:"192.168.1.10"`
+	Service string `json:"service,omitempty" jsonschema:"Only credentials of this service" example:"ssh"`
+	Source  string `json:"source,omitempty" jsonschema:"Only credentials found by this tool" example:"hydra"`
+	Format  string `json:"format,omitempty" jsonschema:"Export format" enum:"json,csv,userpass" default:"json"`
+}
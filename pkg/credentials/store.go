@@ -0,0 +1,219 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+)
+
+// FileName is the name of the file in the artifact directory the
+// credentials are persisted in
+const FileName = "credentials.json"
+
+// Export formats
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatUserPass = "userpass"
+)
+
+// Credential is a login or cracked hash discovered by a tool
+type Credential struct {
+	Host     string    `json:"host,omitempty"`
+	Port     int       `json:"port,omitempty"`
+	Service  string    `json:"service,omitempty"`
+	Username string    `json:"username,omitempty"`
+	Password string    `json:"password"`
+	Hash     string    `json:"hash,omitempty"`
+	Source   string    `json:"source"`
+	FoundAt  time.Time `json:"found_at"`
+}
+
+// key identifies a credential by host/service for logins and by hash for
+// cracked hashes, so that re-running a tool does not add duplicates
+func (c Credential) key() string {
+	if c.Hash != "" {
+		return "hash\x00" + c.Hash + "\x00" + c.Username
+	}
+	if c.Host == "" {
+		// Cracked password whose hash is unknown
+		return "cracked\x00" + c.Username + "\x00" + c.Password
+	}
+	return strings.Join([]string{"login", c.Host, strconv.Itoa(c.Port), c.Service, c.Username}, "\x00")
+}
+
+// Filter selects credentials, empty fields match everything
+type Filter struct {
+	Host    string `json:"host,omitempty"`
+	Service string `json:"service,omitempty"`
+	Source  string `json:"source,omitempty"`
+}
+
+// matches reports whether c is selected by the filter
+func (f Filter) matches(c Credential) bool {
+	return (f.Host == "" || strings.EqualFold(f.Host, c.Host)) &&
+		(f.Service == "" || strings.EqualFold(f.Service, c.Service)) &&
+		(f.Source == "" || strings.EqualFold(f.Source, c.Source))
+}
+
+// Store keeps the credentials found by tools on the server. Once loaded,
+// it persists them in the artifact directory, so that they survive a
+// restart.
+type Store struct {
+	mu          sync.Mutex
+	credentials map[string]Credential
+	path        string
+}
+
+// DefaultStore is the credential store shared by the tools and handlers
+var DefaultStore = NewStore()
+
+// NewStore creates a new, empty Store
+func NewStore() *Store {
+	return &Store{credentials: make(map[string]Credential)}
+}
+
+// Add records a credential, replacing an earlier one with the same key.
+// It reports whether the credential was new or changed.
+func (s *Store) Add(c Credential) bool {
+	if c.FoundAt.IsZero() {
+		c.FoundAt = time.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := c.key()
+	if existing, ok := s.credentials[key]; ok && existing.Password == c.Password {
+		return false
+	}
+	s.credentials[key] = c
+	if err := s.save(); err != nil {
+		log.Printf("Failed to save credentials: %v", err)
+	}
+	return true
+}
+
+// Load reads the credentials persisted in the artifact directory by
+// earlier processes and persists the credentials found from now on there
+func (s *Store) Load() error {
+	path := filepath.Join(executor.ArtifactDir, FileName)
+	var list []Credential
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &list); err != nil {
+			return fmt.Errorf("invalid %s: %w", path, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range list {
+		if _, ok := s.credentials[c.key()]; !ok {
+			s.credentials[c.key()] = c
+		}
+	}
+	s.path = path
+	return s.save()
+}
+
+// save writes the credentials to the store's file, readable only by the
+// server's user, if it has been loaded. Must be called with s.mu held.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	list := make([]Credential, 0, len(s.credentials))
+	for _, c := range s.credentials {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].FoundAt.Before(list[j].FoundAt) })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	// Replace the file at once, so that a crash cannot leave half of it
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// List returns the credentials matching filter, sorted by host, service and user
+func (s *Store) List(filter Filter) []Credential {
+	s.mu.Lock()
+	list := make([]Credential, 0, len(s.credentials))
+	for _, c := range s.credentials {
+		if filter.matches(c) {
+			list = append(list, c)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.Username != b.Username {
+			return a.Username < b.Username
+		}
+		return a.Hash < b.Hash
+	})
+	return list
+}
+
+// Export returns the credentials matching filter in the given format:
+// json, csv or userpass (one "user:password" per line, usable with hydra -C)
+func (s *Store) Export(filter Filter, format string) (string, error) {
+	list := s.List(filter)
+	switch format {
+	case "", FormatJSON:
+		data, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	case FormatCSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"host", "port", "service", "username", "password", "hash", "source", "found_at"})
+		for _, c := range list {
+			port := ""
+			if c.Port != 0 {
+				port = strconv.Itoa(c.Port)
+			}
+			w.Write([]string{c.Host, port, c.Service, c.Username, c.Password, c.Hash, c.Source, c.FoundAt.Format(time.RFC3339)})
+		}
+		w.Flush()
+		return buf.String(), w.Error()
+	case FormatUserPass:
+		var b strings.Builder
+		for _, c := range list {
+			fmt.Fprintf(&b, "%s:%s\n", c.Username, c.Password)
+		}
+		return b.String(), nil
+	default:
+		return "", fmt.Errorf("invalid format: %s. Must be one of: json, csv, userpass", format)
+	}
+}
@@ -0,0 +1,203 @@
+package executor
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultMaxOutputSize is the default amount of output kept in memory per stream
+	DefaultMaxOutputSize = 1 << 20
+)
+
+var (
+	// MaxOutputSize is the amount of output kept in memory per stream. Output
+	// beyond it is written to the run's artifact directory and only the head
+	// and tail are returned.
+	MaxOutputSize = DefaultMaxOutputSize
+
+	// ArtifactDir is the directory holding one sub directory per run
+	ArtifactDir = filepath.Join(os.TempDir(), "mcp-kali-server", "runs")
+)
+
+// NewRunID generates a random run identifier
+func NewRunID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate run ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RunDir returns the artifact directory of the run, creating it if necessary
+func RunDir(runID string) (string, error) {
+	if runID == "" || strings.ContainsAny(runID, `/\`) || runID == "." || runID == ".." {
+		return "", fmt.Errorf("invalid run ID: %q", runID)
+	}
+	dir := filepath.Join(ArtifactDir, runID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create run directory: %w", err)
+	}
+	return dir, nil
+}
+
+// SetMaxOutputSize sets the amount of output kept in memory per stream
+func SetMaxOutputSize(size int) {
+	MaxOutputSize = size
+}
+
+// SetArtifactDir sets the directory holding run artifacts
+func SetArtifactDir(dir string) {
+	ArtifactDir = dir
+}
+
+// capture keeps the output of one stream in memory up to max bytes. Once
+// that is exceeded, the complete output is written to a file in the run
+// directory and only the first and last max/2 bytes are kept in memory.
+type capture struct {
+	runID  string
+	stream string
+	max    int
+	total  int64
+	buf    []byte // all output until the limit is hit, then the head
+	tail   []byte
+	file   *os.File
+	path   string
+}
+
+// newCapture creates a capture for stream of the given run
+func newCapture(runID, stream string, max int) *capture {
+	return &capture{runID: runID, stream: stream, max: max}
+}
+
+// Write implements io.Writer
+func (c *capture) Write(p []byte) (int, error) {
+	c.total += int64(len(p))
+	if c.max <= 0 {
+		c.buf = append(c.buf, p...)
+		return len(p), nil
+	}
+
+	if c.tail == nil && len(c.buf)+len(p) <= c.max {
+		c.buf = append(c.buf, p...)
+		return len(p), nil
+	}
+
+	if c.tail == nil {
+		// First overflow: spill what we have so far and split it into head and tail
+		all := append(c.buf, p...)
+		c.spill(all)
+		head := c.max / 2
+		for i := 0; i < utf8.UTFMax-1 && head > 0 && !utf8.RuneStart(all[head]); i++ {
+			head--
+		}
+		c.buf = append([]byte(nil), all[:head]...)
+		c.tail = []byte{}
+		c.appendTail(all[head:])
+		return len(p), nil
+	}
+
+	c.spill(p)
+	c.appendTail(p)
+	return len(p), nil
+}
+
+// appendTail adds data to the tail, keeping only the last max/2 bytes
+func (c *capture) appendTail(data []byte) {
+	keep := c.max - c.max/2
+	if len(data) >= keep {
+		c.tail = append(c.tail[:0], data[len(data)-keep:]...)
+		return
+	}
+	if over := len(c.tail) + len(data) - keep; over > 0 {
+		c.tail = append(c.tail[:0], c.tail[over:]...)
+	}
+	c.tail = append(c.tail, data...)
+}
+
+// tailText returns the tail without a partial UTF-8 character at its start
+func (c *capture) tailText() []byte {
+	tail := c.tail
+	for i := 0; i < utf8.UTFMax-1 && len(tail) > 0 && !utf8.RuneStart(tail[0]); i++ {
+		tail = tail[1:]
+	}
+	return tail
+}
+
+// spill writes data to the artifact file, opening it on first use. Errors
+// are logged and disable spilling; the head and tail are still kept.
+func (c *capture) spill(data []byte) {
+	if c.file == nil && c.path == "" {
+		c.path = "-"
+		dir, err := RunDir(c.runID)
+		if err != nil {
+			log.Printf("Failed to spill %s: %v", c.stream, err)
+			return
+		}
+		path := filepath.Join(dir, c.stream+".log")
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			log.Printf("Failed to spill %s: %v", c.stream, err)
+			return
+		}
+		c.file = file
+		c.path = path
+		log.Printf("Output of %s exceeds %d bytes, writing it to %s", c.stream, c.max, path)
+	}
+	if c.file == nil {
+		return
+	}
+	if _, err := c.file.Write(data); err != nil {
+		log.Printf("Failed to spill %s: %v", c.stream, err)
+		c.file.Close()
+		c.file = nil
+		c.path = "-"
+	}
+}
+
+// Close closes the artifact file, if any
+func (c *capture) Close() {
+	if c.file != nil {
+		c.file.Close()
+		c.file = nil
+	}
+}
+
+// Len returns the total number of bytes written to the stream
+func (c *capture) Len() int64 {
+	return c.total
+}
+
+// Truncated reports whether part of the output is not kept in memory
+func (c *capture) Truncated() bool {
+	return c.tail != nil
+}
+
+// File returns the path of the file holding the complete output, if any
+func (c *capture) File() string {
+	if c.path == "-" {
+		return ""
+	}
+	return c.path
+}
+
+// String returns the captured output, with a marker in place of the
+// omitted middle part when it was truncated
+func (c *capture) String() string {
+	if c.tail == nil {
+		return string(c.buf)
+	}
+	tail := c.tailText()
+	omitted := c.total - int64(len(c.buf)) - int64(len(tail))
+	where := "discarded"
+	if file := c.File(); file != "" {
+		where = "full output in " + file
+	}
+	return fmt.Sprintf("%s\n... [%d bytes omitted, %s] ...\n%s", c.buf, omitted, where, tail)
+}
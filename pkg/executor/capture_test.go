@@ -0,0 +1,168 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useArtifactDir points ArtifactDir at dir for the duration of the test
+func useArtifactDir(t *testing.T, dir string) {
+	t.Helper()
+	old := ArtifactDir
+	ArtifactDir = dir
+	t.Cleanup(func() { ArtifactDir = old })
+}
+
+// TestCapture writes output in chunks and checks what is kept in memory
+// and what is spilled to the run directory
+func TestCapture(t *testing.T) {
+	tests := []struct {
+		name   string
+		max    int
+		writes []string
+		// want is the captured output with "%s" in place of the spill file
+		want    string
+		spilled bool
+	}{
+		{
+			name:   "below limit",
+			max:    16,
+			writes: []string{"hello ", "world"},
+			want:   "hello world",
+		},
+		{
+			name:   "at limit",
+			max:    16,
+			writes: []string{"0123456789", "abcdef"},
+			want:   "0123456789abcdef",
+		},
+		{
+			name:   "unlimited",
+			max:    0,
+			writes: []string{strings.Repeat("x", 100), strings.Repeat("y", 100)},
+			want:   strings.Repeat("x", 100) + strings.Repeat("y", 100),
+		},
+		{
+			name:    "single write over limit",
+			max:     10,
+			writes:  []string{"0123456789abcdefghij"},
+			want:    "01234\n... [10 bytes omitted, full output in %s] ...\nfghij",
+			spilled: true,
+		},
+		{
+			name:    "overflow across writes",
+			max:     10,
+			writes:  []string{"01234567", "89ab", "cd", "efghij"},
+			want:    "01234\n... [10 bytes omitted, full output in %s] ...\nfghij",
+			spilled: true,
+		},
+		{
+			name:    "small writes after overflow",
+			max:     10,
+			writes:  []string{"0123456789a", "b", "c", "d"},
+			want:    "01234\n... [4 bytes omitted, full output in %s] ...\n9abcd",
+			spilled: true,
+		},
+		{
+			name:    "odd limit",
+			max:     7,
+			writes:  []string{"0123456789"},
+			want:    "012\n... [3 bytes omitted, full output in %s] ...\n6789",
+			spilled: true,
+		},
+		{
+			// The head would end inside the first "é", the tail start inside the second
+			name:    "utf-8 boundaries",
+			max:     8,
+			writes:  []string{"abcé", "fghijé!ü"},
+			want:    "abc\n... [9 bytes omitted, full output in %s] ...\n!ü",
+			spilled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useArtifactDir(t, t.TempDir())
+			c := newCapture("run1", "stdout", tt.max)
+			var all string
+			for _, w := range tt.writes {
+				if n, err := c.Write([]byte(w)); n != len(w) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v", w, n, err)
+				}
+				all += w
+			}
+			c.Close()
+
+			if c.Len() != int64(len(all)) {
+				t.Errorf("Len() = %d, want %d", c.Len(), len(all))
+			}
+			if c.Truncated() != tt.spilled {
+				t.Errorf("Truncated() = %v, want %v", c.Truncated(), tt.spilled)
+			}
+
+			file := c.File()
+			want := tt.want
+			if tt.spilled {
+				wantFile := filepath.Join(ArtifactDir, "run1", "stdout.log")
+				if file != wantFile {
+					t.Fatalf("File() = %q, want %q", file, wantFile)
+				}
+				data, err := os.ReadFile(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != all {
+					t.Errorf("spill file holds %q, want %q", data, all)
+				}
+				want = strings.Replace(want, "%s", file, 1)
+			} else if file != "" {
+				t.Errorf("File() = %q, want none", file)
+			}
+			if got := c.String(); got != want {
+				t.Errorf("String() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// TestCaptureSpillFailure checks that head and tail are still kept when
+// the run directory cannot be created
+func TestCaptureSpillFailure(t *testing.T) {
+	notDir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notDir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	useArtifactDir(t, notDir)
+
+	c := newCapture("run1", "stderr", 10)
+	c.Write([]byte("0123456789"))
+	c.Write([]byte("abcdefghij"))
+	c.Close()
+
+	if file := c.File(); file != "" {
+		t.Errorf("File() = %q, want none", file)
+	}
+	want := "01234\n... [10 bytes omitted, discarded] ...\nfghij"
+	if got := c.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+// TestRunDir rejects run IDs that would escape the artifact directory
+func TestRunDir(t *testing.T) {
+	useArtifactDir(t, t.TempDir())
+	for _, id := range []string{"", ".", "..", "../x", `a\b`, "a/b"} {
+		if dir, err := RunDir(id); err == nil {
+			t.Errorf("RunDir(%q) = %q, want an error", id, dir)
+		}
+	}
+	dir, err := RunDir("0123abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Errorf("RunDir did not create %s: %v", dir, err)
+	}
+}
@@ -1,15 +1,17 @@
 package executor
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/security"
 )
 
 const (
@@ -20,6 +22,10 @@ const (
 var (
 	// GlobalTimeout is the global command execution timeout that can be configured
 	GlobalTimeout = DefaultTimeout
+
+	// KillGracePeriod is how long a process group gets to exit after SIGTERM
+	// before it is killed with SIGKILL
+	KillGracePeriod = 5 * time.Second
 )
 
 // Result represents the result of a command execution
@@ -29,86 +35,206 @@ type Result struct {
 	ReturnCode   int    `json:"return_code"`
 	Success      bool   `json:"success"`
 	TimedOut     bool   `json:"timed_out"`
+	Canceled     bool   `json:"canceled"`
+	Signal       string `json:"signal,omitempty"`
 	PartialResults bool `json:"partial_results"`
+	QueuePosition  int     `json:"queue_position,omitempty"`
+	QueueWait      float64 `json:"queue_wait_seconds,omitempty"`
+	RunID          string  `json:"run_id,omitempty"`
+	Truncated      bool    `json:"truncated,omitempty"`
+	StdoutFile     string  `json:"stdout_file,omitempty"`
+	StderrFile     string  `json:"stderr_file,omitempty"`
+	LimitExceeded  string  `json:"limit_exceeded,omitempty"`
 }
 
-// CommandExecutor handles command execution with timeout management
+// CommandExecutor handles command execution with timeout management.
+// When Path is set the binary is started directly with Args as its argument
+// vector; otherwise Command is interpreted by "sh -c". Tool is the name
+// used for per-tool concurrency limits. Output exceeding MaxOutputSize is
+// written to the artifact directory of RunID, which is generated if empty.
 type CommandExecutor struct {
 	Command   string
+	Path      string
+	Args      []string
+	Tool      string
+	RunID     string
 	Timeout   time.Duration
-	stdout    strings.Builder
-	stderr    strings.Builder
+	Limiter   *Limiter
+	stdout    *capture
+	stderr    *capture
 	returnCode int
 	timedOut  bool
+	canceled  bool
+	signal    string
 	mu        sync.Mutex
 }
 
-// NewCommandExecutor creates a new CommandExecutor
+// NewCommandExecutor creates a new CommandExecutor that runs command through the shell
 func NewCommandExecutor(command string, timeout time.Duration) *CommandExecutor {
 	if timeout == 0 {
 		timeout = DefaultTimeout
 	}
 	return &CommandExecutor{
 		Command: command,
+		Tool:    "sh",
+		Timeout: timeout,
+	}
+}
+
+// NewArgsExecutor creates a new CommandExecutor that runs path with args without a shell
+func NewArgsExecutor(path string, args []string, timeout time.Duration) *CommandExecutor {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	return &CommandExecutor{
+		Path:    path,
+		Args:    args,
+		Tool:    filepath.Base(path),
 		Timeout: timeout,
 	}
 }
 
+// String returns a printable representation of the command
+func (ce *CommandExecutor) String() string {
+	if ce.Path == "" {
+		return ce.Command
+	}
+	parts := make([]string, 0, len(ce.Args)+1)
+	parts = append(parts, ce.Path)
+	for _, arg := range ce.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$`;&|<>()*?[]{}~!#") {
+			arg = security.EscapeShellArg(arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
 // Execute runs the command and returns the result
 func (ce *CommandExecutor) Execute() (*Result, error) {
-	log.Printf("Executing command: %s", ce.Command)
+	return ce.ExecuteContext(context.Background())
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), ce.Timeout)
-	defer cancel()
+// ExecuteContext runs the command and returns the result. The command is
+// killed when either the timeout expires or parent is canceled. The command
+// waits for a free slot in the limiter first; the timeout only starts once
+// it is running.
+func (ce *CommandExecutor) ExecuteContext(parent context.Context) (*Result, error) {
+	limiter := ce.Limiter
+	if limiter == nil {
+		limiter = DefaultLimiter
+	}
+	queuedAt := time.Now()
+	release, position, err := limiter.Acquire(parent, ce.Tool, priorityFrom(parent), queueHandlerFrom(parent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue command: %w", err)
+	}
+	defer release()
+	queueWait := time.Since(queuedAt)
+	if position > 0 {
+		log.Printf("Command waited %v at queue position %d", queueWait.Round(time.Millisecond), position)
+	}
 
-	cmd := exec.CommandContext(ctx, "sh", "-c", ce.Command)
+	if ce.RunID == "" {
+		runID, err := NewRunID()
+		if err != nil {
+			return nil, err
+		}
+		ce.RunID = runID
+	}
 
-	// Create pipes for stdout and stderr
-	stdoutPipe, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	log.Printf("Executing command: %s", ce)
+
+	// A wall clock limit of the tool takes precedence over a longer timeout
+	limits := ResourceLimitsFor(ce.Tool)
+	timeout := ce.Timeout
+	wallClockLimited := false
+	if wallClock := limits.wallClock(); wallClock > 0 && wallClock < timeout {
+		timeout = wallClock
+		wallClockLimited = true
 	}
 
-	stderrPipe, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if ce.Path != "" {
+		cmd = exec.CommandContext(ctx, ce.Path, ce.Args...)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", ce.Command)
 	}
 
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start command: %w", err)
+	// Run the command in its own process group so that children spawned by
+	// the tool (or by the shell) are terminated together with it
+	setupProcessGroup(cmd)
+	resources := prepareResources(cmd, ce.RunID, limits)
+
+	var killTimer *time.Timer
+	cmd.Cancel = func() error {
+		killTimer = time.AfterFunc(KillGracePeriod, func() {
+			if err := killProcessGroup(cmd.Process); err != nil {
+				log.Printf("Failed to kill process group %d: %v", cmd.Process.Pid, err)
+			}
+		})
+		return terminateProcessGroup(cmd.Process)
 	}
+	// Stop waiting for output once the grace period is over, in case an
+	// orphaned child still holds the pipes open
+	cmd.WaitDelay = KillGracePeriod
 
-	// Create wait group for goroutines
-	var wg sync.WaitGroup
-	wg.Add(2)
+	ce.stdout = newCapture(ce.RunID, StreamStdout, MaxOutputSize)
+	ce.stderr = newCapture(ce.RunID, StreamStderr, MaxOutputSize)
+	defer ce.stdout.Close()
+	defer ce.stderr.Close()
 
-	// Read stdout
-	go func() {
-		defer wg.Done()
-		ce.readPipe(stdoutPipe, &ce.stdout)
-	}()
+	handler := outputHandlerFrom(parent)
+	var seq uint64
+	cmd.Stdout = &outputWriter{mu: &ce.mu, seq: &seq, capture: ce.stdout, stream: StreamStdout, handler: handler}
+	cmd.Stderr = &outputWriter{mu: &ce.mu, seq: &seq, capture: ce.stderr, stream: StreamStderr, handler: handler}
 
-	// Read stderr
-	go func() {
-		defer wg.Done()
-		ce.readPipe(stderrPipe, &ce.stderr)
-	}()
+	// Start the command
+	if err := cmd.Start(); err != nil {
+		resources.finish(nil)
+		return nil, fmt.Errorf("failed to start command: %w", err)
+	}
 
-	// Wait for command to complete
+	// Wait for command to complete and its output to be copied
 	cmdErr := cmd.Wait()
+	interrupted := killTimer != nil
+	if interrupted {
+		killTimer.Stop()
+	}
 
-	// Wait for all output to be read
-	wg.Wait()
+	// Clean up anything the command left running in its process group
+	if err := cleanupProcessGroup(cmd.Process); err != nil {
+		log.Printf("Failed to clean up process group %d: %v", cmd.Process.Pid, err)
+	}
 
-	// Check if command timed out
-	if ctx.Err() == context.DeadlineExceeded {
+	ce.signal = exitSignal(cmd.ProcessState)
+	if ce.signal != "" {
+		log.Printf("Command terminated by %s", ce.signal)
+	}
+	limitExceeded := resources.finish(cmd.ProcessState)
+
+	// Check if command was canceled by the caller or timed out
+	if interrupted && parent.Err() != nil {
+		ce.canceled = true
+		ce.returnCode = -1
+		log.Printf("Command canceled: %s", ce)
+	} else if interrupted && ctx.Err() == context.DeadlineExceeded {
 		ce.timedOut = true
 		ce.returnCode = -1
-		log.Printf("Command timed out after %v", ce.Timeout)
+		if wallClockLimited && limitExceeded == "" {
+			limitExceeded = LimitWallClock
+		}
+		log.Printf("Command timed out after %v", timeout)
 	} else if cmdErr != nil {
-		if exitError, ok := cmdErr.(*exec.ExitError); ok {
+		var exitError *exec.ExitError
+		if errors.As(cmdErr, &exitError) {
 			ce.returnCode = exitError.ExitCode()
+		} else if errors.Is(cmdErr, exec.ErrWaitDelay) {
+			// The command exited but an orphaned child kept its output open
+			ce.returnCode = cmd.ProcessState.ExitCode()
 		} else {
 			ce.returnCode = -1
 		}
@@ -116,9 +242,14 @@ func (ce *CommandExecutor) Execute() (*Result, error) {
 		ce.returnCode = 0
 	}
 
+	if limitExceeded != "" {
+		log.Printf("Command exceeded its %s limit", limitExceeded)
+	}
+
 	// Determine success
+	hasOutput := ce.stdout.Len() > 0 || ce.stderr.Len() > 0
 	success := ce.returnCode == 0
-	if ce.timedOut && (ce.stdout.Len() > 0 || ce.stderr.Len() > 0) {
+	if ce.timedOut && hasOutput {
 		success = true // Consider it a success if we have output even with timeout
 	}
 
@@ -128,25 +259,73 @@ func (ce *CommandExecutor) Execute() (*Result, error) {
 		ReturnCode:     ce.returnCode,
 		Success:        success,
 		TimedOut:       ce.timedOut,
-		PartialResults: ce.timedOut && (ce.stdout.Len() > 0 || ce.stderr.Len() > 0),
+		Canceled:       ce.canceled,
+		Signal:         ce.signal,
+		PartialResults: ce.timedOut && hasOutput,
+		QueuePosition:  position,
+		QueueWait:      queueWaitSeconds(position, queueWait),
+		RunID:          ce.RunID,
+		Truncated:      ce.stdout.Truncated() || ce.stderr.Truncated(),
+		StdoutFile:     ce.stdout.File(),
+		StderrFile:     ce.stderr.File(),
+		LimitExceeded:  limitExceeded,
 	}, nil
 }
 
-// readPipe reads from a pipe and writes to a string builder
-func (ce *CommandExecutor) readPipe(pipe io.Reader, builder *strings.Builder) {
-	scanner := bufio.NewScanner(pipe)
-	for scanner.Scan() {
-		ce.mu.Lock()
-		builder.WriteString(scanner.Text())
-		builder.WriteString("\n")
-		ce.mu.Unlock()
+// queueWaitSeconds returns how long a queued command waited, 0 if it was not queued
+func queueWaitSeconds(position int, wait time.Duration) float64 {
+	if position == 0 {
+		return 0
+	}
+	return wait.Seconds()
+}
+
+// outputWriter collects command output into a capture and forwards it to
+// the output handler, if any. Writes of both streams share a lock and a
+// sequence counter so that the handler sees chunks in the order they were read.
+type outputWriter struct {
+	mu      *sync.Mutex
+	seq     *uint64
+	capture *capture
+	stream  string
+	handler OutputHandler
+}
+
+// Write implements io.Writer
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n, err := w.capture.Write(p)
+	*w.seq++
+	if w.handler != nil {
+		w.handler(Chunk{Seq: *w.seq, Stream: w.stream, Data: p, Time: time.Now()})
 	}
+	return n, err
 }
 
-// ExecuteCommand is a convenience function to execute a command
+// ExecuteCommand is a convenience function to execute a shell command line.
+// It is the explicit opt-in path for arbitrary commands; built-in tools
+// should use ExecuteArgs instead.
 func ExecuteCommand(command string) (*Result, error) {
+	return ExecuteCommandContext(context.Background(), command)
+}
+
+// ExecuteCommandContext is like ExecuteCommand but kills the command when ctx is canceled
+func ExecuteCommandContext(ctx context.Context, command string) (*Result, error) {
 	executor := NewCommandExecutor(command, GlobalTimeout)
-	return executor.Execute()
+	return executor.ExecuteContext(ctx)
+}
+
+// ExecuteArgs is a convenience function to execute a binary with an argument
+// vector. No shell is involved, so arguments are passed to the binary verbatim.
+func ExecuteArgs(path string, args ...string) (*Result, error) {
+	return ExecuteArgsContext(context.Background(), path, args...)
+}
+
+// ExecuteArgsContext is like ExecuteArgs but kills the command when ctx is canceled
+func ExecuteArgsContext(ctx context.Context, path string, args ...string) (*Result, error) {
+	executor := NewArgsExecutor(path, args, GlobalTimeout)
+	return executor.ExecuteContext(ctx)
 }
 
 // SetGlobalTimeout sets the global command execution timeout
@@ -0,0 +1,300 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultMaxConcurrent is the default number of commands running at the same time
+	DefaultMaxConcurrent = 8
+	// DefaultMaxQueue is the default number of commands waiting for a free slot
+	DefaultMaxQueue = 64
+)
+
+// ErrQueueFull is returned when a command cannot be queued because the queue is full
+var ErrQueueFull = errors.New("execution queue is full, try again later")
+
+// LimiterConfig configures how many commands may run concurrently
+type LimiterConfig struct {
+	// MaxConcurrent limits the number of commands running at once, 0 means unlimited
+	MaxConcurrent int
+	// MaxPerTool limits the number of concurrent runs of any single tool, 0 means unlimited
+	MaxPerTool int
+	// ToolLimits overrides MaxPerTool for individual tools
+	ToolLimits map[string]int
+	// MaxQueue limits the number of waiting commands, 0 means unlimited.
+	// Commands that would exceed it are rejected with ErrQueueFull.
+	MaxQueue int
+}
+
+// QueueHandler is notified about the queue position of a command while it
+// waits. Position 0 means the command has been allowed to start.
+type QueueHandler func(position int)
+
+// Limiter enforces global and per-tool concurrency limits with a priority
+// queue that is FIFO among commands of the same priority
+type Limiter struct {
+	mu      sync.Mutex
+	config  LimiterConfig
+	running int
+	perTool map[string]int
+	queue   []*waiter
+	seq     uint64
+}
+
+// waiter is a command waiting in the queue
+type waiter struct {
+	tool     string
+	priority int
+	seq      uint64
+	granted  bool
+	ready    chan struct{}
+	handler  QueueHandler
+}
+
+// DefaultLimiter is the limiter used by every CommandExecutor
+var DefaultLimiter = NewLimiter(LimiterConfig{
+	MaxConcurrent: DefaultMaxConcurrent,
+	MaxQueue:      DefaultMaxQueue,
+})
+
+// NewLimiter creates a new Limiter
+func NewLimiter(config LimiterConfig) *Limiter {
+	return &Limiter{
+		config:  config,
+		perTool: make(map[string]int),
+	}
+}
+
+// ConfigureLimiter replaces the default limiter
+func ConfigureLimiter(config LimiterConfig) {
+	DefaultLimiter = NewLimiter(config)
+}
+
+// Acquire waits until tool may run and returns a function that releases the
+// slot, together with the queue position the command started waiting at
+// (0 if it did not have to wait). Higher priorities are served first.
+func (l *Limiter) Acquire(ctx context.Context, tool string, priority int, handler QueueHandler) (func(), int, error) {
+	l.mu.Lock()
+	l.seq++
+	w := &waiter{
+		tool:     tool,
+		priority: priority,
+		seq:      l.seq,
+		ready:    make(chan struct{}),
+		handler:  handler,
+	}
+	l.enqueue(w)
+	notifications := l.dispatch()
+
+	position := 0
+	if !w.granted {
+		position = l.position(w)
+		if l.config.MaxQueue > 0 && len(l.queue) > l.config.MaxQueue {
+			l.remove(w)
+			l.mu.Unlock()
+			return nil, 0, ErrQueueFull
+		}
+		notifications = append(notifications, l.positions()...)
+	}
+	l.mu.Unlock()
+	notify(notifications)
+
+	select {
+	case <-w.ready:
+		return l.releaseFunc(tool), position, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		if w.granted {
+			// Lost the race against dispatch, give the slot back
+			l.mu.Unlock()
+			l.releaseFunc(tool)()
+			return nil, position, ctx.Err()
+		}
+		l.remove(w)
+		notifications := l.positions()
+		l.mu.Unlock()
+		notify(notifications)
+		return nil, position, ctx.Err()
+	}
+}
+
+// Stats returns the number of running and queued commands
+func (l *Limiter) Stats() (running int, queued int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.running, len(l.queue)
+}
+
+// Capacity returns the number of runs of tool that may run at once, 0 if
+// that is unlimited. Callers fanning out work use it to avoid flooding the
+// queue.
+func (l *Limiter) Capacity(tool string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	capacity := l.config.MaxConcurrent
+	if limit := l.toolLimit(tool); limit > 0 && (capacity == 0 || limit < capacity) {
+		capacity = limit
+	}
+	return capacity
+}
+
+// releaseFunc returns a function that frees the slot held by tool exactly once
+func (l *Limiter) releaseFunc(tool string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.running--
+			l.perTool[tool]--
+			if l.perTool[tool] <= 0 {
+				delete(l.perTool, tool)
+			}
+			notifications := l.dispatch()
+			notifications = append(notifications, l.positions()...)
+			l.mu.Unlock()
+			notify(notifications)
+		})
+	}
+}
+
+// enqueue inserts w ordered by priority, then arrival. Must be called with l.mu held.
+func (l *Limiter) enqueue(w *waiter) {
+	i := sort.Search(len(l.queue), func(i int) bool {
+		return l.queue[i].priority < w.priority
+	})
+	l.queue = append(l.queue, nil)
+	copy(l.queue[i+1:], l.queue[i:])
+	l.queue[i] = w
+}
+
+// remove deletes w from the queue. Must be called with l.mu held.
+func (l *Limiter) remove(w *waiter) {
+	for i, queued := range l.queue {
+		if queued == w {
+			l.queue = append(l.queue[:i], l.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// position returns the 1-based queue position of w. Must be called with l.mu held.
+func (l *Limiter) position(w *waiter) int {
+	for i, queued := range l.queue {
+		if queued == w {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// dispatch starts as many queued commands as the limits allow. A command
+// whose tool is at its limit does not block commands for other tools behind
+// it. Must be called with l.mu held.
+func (l *Limiter) dispatch() []notification {
+	var started []notification
+	for i := 0; i < len(l.queue); {
+		if l.config.MaxConcurrent > 0 && l.running >= l.config.MaxConcurrent {
+			break
+		}
+		w := l.queue[i]
+		if limit := l.toolLimit(w.tool); limit > 0 && l.perTool[w.tool] >= limit {
+			i++
+			continue
+		}
+		l.queue = append(l.queue[:i], l.queue[i+1:]...)
+		l.running++
+		l.perTool[w.tool]++
+		w.granted = true
+		close(w.ready)
+		if w.handler != nil {
+			started = append(started, notification{w.handler, 0})
+		}
+	}
+	return started
+}
+
+// positions returns the current position of every queued command. Must be called with l.mu held.
+func (l *Limiter) positions() []notification {
+	var list []notification
+	for i, w := range l.queue {
+		if w.handler != nil {
+			list = append(list, notification{w.handler, i + 1})
+		}
+	}
+	return list
+}
+
+// toolLimit returns the concurrency limit of tool. Must be called with l.mu held.
+func (l *Limiter) toolLimit(tool string) int {
+	if limit, ok := l.config.ToolLimits[tool]; ok {
+		return limit
+	}
+	return l.config.MaxPerTool
+}
+
+// notification is a pending call of a queue handler
+type notification struct {
+	handler  QueueHandler
+	position int
+}
+
+// notify delivers queue notifications outside of the limiter lock
+func notify(notifications []notification) {
+	for _, n := range notifications {
+		n.handler(n.position)
+	}
+}
+
+// ParseToolLimits parses per-tool limits in the form "nmap=2,hydra=1"
+func ParseToolLimits(s string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		tool, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid tool limit %q, expected tool=N", item)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid limit for %s: %s", tool, value)
+		}
+		limits[strings.TrimSpace(tool)] = limit
+	}
+	return limits, nil
+}
+
+type priorityKey struct{}
+type queueHandlerKey struct{}
+
+// WithPriority returns a context that queues commands with the given priority.
+// Commands with a higher priority are started first.
+func WithPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// WithQueueHandler returns a context that reports queue positions of commands to handler
+func WithQueueHandler(ctx context.Context, handler QueueHandler) context.Context {
+	return context.WithValue(ctx, queueHandlerKey{}, handler)
+}
+
+// priorityFrom returns the priority stored in ctx
+func priorityFrom(ctx context.Context) int {
+	priority, _ := ctx.Value(priorityKey{}).(int)
+	return priority
+}
+
+// queueHandlerFrom returns the queue handler stored in ctx, if any
+func queueHandlerFrom(ctx context.Context) QueueHandler {
+	handler, _ := ctx.Value(queueHandlerKey{}).(QueueHandler)
+	return handler
+}
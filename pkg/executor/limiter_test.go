@@ -0,0 +1,277 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// waitQueued waits until n commands are waiting in the queue of l
+func waitQueued(t *testing.T, l *Limiter, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, queued := l.Stats(); queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			_, queued := l.Stats()
+			t.Fatalf("queued = %d, want %d", queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// acquire acquires a slot for tool without waiting and fails the test otherwise
+func acquire(t *testing.T, l *Limiter, tool string) func() {
+	t.Helper()
+	release, position, err := l.Acquire(context.Background(), tool, 0, nil)
+	if err != nil {
+		t.Fatalf("Acquire(%s): %v", tool, err)
+	}
+	if position != 0 {
+		t.Fatalf("Acquire(%s) waited at position %d", tool, position)
+	}
+	return release
+}
+
+// TestLimiterOrder queues commands behind a full limiter one at a time and
+// checks that they start by descending priority, then in arrival order
+func TestLimiterOrder(t *testing.T) {
+	type queued struct {
+		name     string
+		priority int
+	}
+	tests := []struct {
+		name   string
+		queued []queued
+		want   []string
+	}{
+		{
+			name:   "fifo",
+			queued: []queued{{"a", 0}, {"b", 0}, {"c", 0}},
+			want:   []string{"a", "b", "c"},
+		},
+		{
+			name:   "priority",
+			queued: []queued{{"low", -1}, {"normal", 0}, {"high", 10}},
+			want:   []string{"high", "normal", "low"},
+		},
+		{
+			name:   "fifo within priority",
+			queued: []queued{{"a", 0}, {"b", 5}, {"c", 0}, {"d", 5}},
+			want:   []string{"b", "d", "a", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(LimiterConfig{MaxConcurrent: 1})
+			release := acquire(t, l, "nmap")
+
+			started := make(chan string)
+			for i, q := range tt.queued {
+				go func() {
+					done, _, err := l.Acquire(context.Background(), "nmap", q.priority, nil)
+					if err != nil {
+						t.Errorf("Acquire(%s): %v", q.name, err)
+						started <- ""
+						return
+					}
+					started <- q.name
+					done()
+				}()
+				waitQueued(t, l, i+1)
+			}
+
+			release()
+			var got []string
+			for range tt.queued {
+				got = append(got, <-started)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("started %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLimiterQueueFull checks that commands beyond MaxQueue are rejected
+// and that queue positions are reported to the handler
+func TestLimiterQueueFull(t *testing.T) {
+	l := NewLimiter(LimiterConfig{MaxConcurrent: 1, MaxQueue: 2})
+	release := acquire(t, l, "nmap")
+
+	positions := make(chan int, 2)
+	for i := 1; i <= 2; i++ {
+		go func() {
+			done, position, err := l.Acquire(context.Background(), "nmap", 0, nil)
+			if err != nil {
+				t.Errorf("Acquire: %v", err)
+				return
+			}
+			positions <- position
+			done()
+		}()
+		waitQueued(t, l, i)
+	}
+
+	if _, _, err := l.Acquire(context.Background(), "nmap", 0, nil); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Acquire with full queue: err = %v, want %v", err, ErrQueueFull)
+	}
+	// A higher priority does not jump a full queue either
+	if _, _, err := l.Acquire(context.Background(), "nmap", 10, nil); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Acquire with full queue and priority: err = %v, want %v", err, ErrQueueFull)
+	}
+	if running, queued := l.Stats(); running != 1 || queued != 2 {
+		t.Errorf("Stats() = %d, %d, want 1, 2", running, queued)
+	}
+
+	release()
+	got := []int{<-positions, <-positions}
+	if got[0]+got[1] != 3 {
+		t.Errorf("waited at positions %v, want 1 and 2", got)
+	}
+	if running, queued := l.Stats(); running != 0 || queued != 0 {
+		t.Errorf("Stats() after release = %d, %d, want 0, 0", running, queued)
+	}
+}
+
+// TestLimiterToolLimits checks that a tool at its limit waits while other
+// tools behind it in the queue are started
+func TestLimiterToolLimits(t *testing.T) {
+	l := NewLimiter(LimiterConfig{
+		MaxConcurrent: 3,
+		MaxPerTool:    2,
+		ToolLimits:    map[string]int{"hydra": 1},
+	})
+	release := acquire(t, l, "hydra")
+
+	hydra := make(chan func())
+	go func() {
+		done, _, err := l.Acquire(context.Background(), "hydra", 0, nil)
+		if err != nil {
+			t.Errorf("Acquire(hydra): %v", err)
+			close(hydra)
+			return
+		}
+		hydra <- done
+	}()
+	waitQueued(t, l, 1)
+
+	// nmap is not blocked by the waiting hydra run, up to MaxPerTool
+	acquire(t, l, "nmap")
+	if running, queued := l.Stats(); running != 2 || queued != 1 {
+		t.Errorf("Stats() = %d, %d, want 2, 1", running, queued)
+	}
+	acquire(t, l, "gobuster")
+
+	release()
+	done, ok := <-hydra
+	if !ok {
+		return
+	}
+	defer done()
+	if running, queued := l.Stats(); running != 3 || queued != 0 {
+		t.Errorf("Stats() after release = %d, %d, want 3, 0", running, queued)
+	}
+}
+
+// TestLimiterCancel checks that a canceled command leaves the queue and
+// that the commands behind it move up
+func TestLimiterCancel(t *testing.T) {
+	l := NewLimiter(LimiterConfig{MaxConcurrent: 1})
+	release := acquire(t, l, "nmap")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, _, err := l.Acquire(ctx, "nmap", 0, nil)
+		canceled <- err
+	}()
+	waitQueued(t, l, 1)
+
+	positions := make(chan int, 4)
+	go func() {
+		done, _, err := l.Acquire(context.Background(), "nmap", 0, func(position int) {
+			positions <- position
+		})
+		if err != nil {
+			t.Errorf("Acquire: %v", err)
+			return
+		}
+		done()
+	}()
+	waitQueued(t, l, 2)
+	if position := <-positions; position != 2 {
+		t.Errorf("position = %d, want 2", position)
+	}
+
+	cancel()
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled Acquire: err = %v, want %v", err, context.Canceled)
+	}
+	if position := <-positions; position != 1 {
+		t.Errorf("position after cancel = %d, want 1", position)
+	}
+
+	release()
+	if position := <-positions; position != 0 {
+		t.Errorf("position after release = %d, want 0", position)
+	}
+}
+
+// TestLimiterCapacity checks the number of runs of a tool allowed at once
+func TestLimiterCapacity(t *testing.T) {
+	tests := []struct {
+		name   string
+		config LimiterConfig
+		want   int
+	}{
+		{name: "unlimited", config: LimiterConfig{}, want: 0},
+		{name: "global", config: LimiterConfig{MaxConcurrent: 8}, want: 8},
+		{name: "per tool", config: LimiterConfig{MaxPerTool: 3}, want: 3},
+		{name: "per tool below global", config: LimiterConfig{MaxConcurrent: 8, MaxPerTool: 3}, want: 3},
+		{name: "global below per tool", config: LimiterConfig{MaxConcurrent: 2, MaxPerTool: 3}, want: 2},
+		{name: "tool limit", config: LimiterConfig{MaxConcurrent: 8, MaxPerTool: 3, ToolLimits: map[string]int{"ping": 5}}, want: 5},
+		{name: "unlimited tool", config: LimiterConfig{MaxConcurrent: 8, MaxPerTool: 3, ToolLimits: map[string]int{"ping": 0}}, want: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewLimiter(tt.config).Capacity("ping"); got != tt.want {
+				t.Errorf("Capacity() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseToolLimits parses per-tool limits from the command line
+func TestParseToolLimits(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    map[string]int
+		wantErr bool
+	}{
+		{input: "", want: map[string]int{}},
+		{input: "nmap=2,hydra=1", want: map[string]int{"nmap": 2, "hydra": 1}},
+		{input: " nmap = 2 , , ping=0 ", want: map[string]int{"nmap": 2, "ping": 0}},
+		{input: "nmap", wantErr: true},
+		{input: "nmap=two", wantErr: true},
+		{input: "nmap=-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseToolLimits(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseToolLimits(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseToolLimits(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
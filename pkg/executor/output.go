@@ -0,0 +1,78 @@
+package executor
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// StreamStdout identifies output written to the command's standard output
+	StreamStdout = "stdout"
+	// StreamStderr identifies output written to the command's standard error
+	StreamStderr = "stderr"
+
+	// EncodingBase64 marks output that is not valid UTF-8 and was base64 encoded
+	EncodingBase64 = "base64"
+)
+
+// Chunk is a piece of command output as it was read from the command.
+// Chunks of both streams are numbered in the order they were received.
+type Chunk struct {
+	Seq    uint64
+	Stream string
+	Data   []byte
+	Time   time.Time
+}
+
+// OutputHandler receives command output as soon as it is produced. Calls
+// are serialized per command, so chunks arrive in Seq order. The Data slice
+// is only valid for the duration of the call.
+type OutputHandler func(chunk Chunk)
+
+type outputHandlerKey struct{}
+
+// WithOutputHandler returns a context that makes commands executed with it
+// report their output to handler. Handlers already present in ctx keep
+// receiving output as well.
+func WithOutputHandler(ctx context.Context, handler OutputHandler) context.Context {
+	if previous := outputHandlerFrom(ctx); previous != nil {
+		next := handler
+		handler = func(chunk Chunk) {
+			previous(chunk)
+			next(chunk)
+		}
+	}
+	return context.WithValue(ctx, outputHandlerKey{}, handler)
+}
+
+// outputHandlerFrom returns the output handler stored in ctx, if any
+func outputHandlerFrom(ctx context.Context) OutputHandler {
+	handler, _ := ctx.Value(outputHandlerKey{}).(OutputHandler)
+	return handler
+}
+
+// EncodeOutput returns data as text if it is valid UTF-8, otherwise base64
+// encoded together with EncodingBase64
+func EncodeOutput(data []byte) (text string, encoding string) {
+	if utf8.Valid(data) {
+		return string(data), ""
+	}
+	return base64.StdEncoding.EncodeToString(data), EncodingBase64
+}
+
+// SplitIncompleteRune splits data into a part ending on a UTF-8 character
+// boundary and the trailing bytes of a character that was cut off, which
+// should be prepended to the next chunk of the same stream
+func SplitIncompleteRune(data []byte) (complete []byte, rest []byte) {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax+1; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i], data[i:]
+			}
+			break
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,58 @@
+//go:build !windows
+// +build !windows
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// setupProcessGroup makes the command the leader of a new process group so
+// that it can be signaled together with every child it spawns
+func setupProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// terminateProcessGroup asks every process in the group to exit
+func terminateProcessGroup(process *os.Process) error {
+	return signalProcessGroup(process, syscall.SIGTERM)
+}
+
+// killProcessGroup forcibly kills every process in the group
+func killProcessGroup(process *os.Process) error {
+	return signalProcessGroup(process, syscall.SIGKILL)
+}
+
+// cleanupProcessGroup kills whatever the command left running in its
+// process group after it exited
+func cleanupProcessGroup(process *os.Process) error {
+	return killProcessGroup(process)
+}
+
+// signalProcessGroup sends sig to the process group led by process
+func signalProcessGroup(process *os.Process, sig syscall.Signal) error {
+	err := syscall.Kill(-process.Pid, sig)
+	if err == syscall.ESRCH {
+		return nil // group already gone
+	}
+	return err
+}
+
+// exitSignal returns the name of the signal that terminated the process, if any
+func exitSignal(state *os.ProcessState) string {
+	if state == nil {
+		return ""
+	}
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+	return unix.SignalName(status.Signal())
+}
@@ -0,0 +1,38 @@
+//go:build windows
+// +build windows
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// setupProcessGroup is a no-op on Windows; the process tree is killed with taskkill instead
+func setupProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcessGroup kills the process tree, Windows has no graceful equivalent of SIGTERM
+func terminateProcessGroup(process *os.Process) error {
+	return killProcessGroup(process)
+}
+
+// killProcessGroup forcibly kills the process and all of its children
+func killProcessGroup(process *os.Process) error {
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(process.Pid)).Run(); err != nil {
+		return process.Kill()
+	}
+	return nil
+}
+
+// cleanupProcessGroup does nothing on Windows. Once the command has exited
+// its PID may already belong to another process, so the tree is only
+// killed on cancel or timeout, while the command is still running.
+func cleanupProcessGroup(process *os.Process) error {
+	return nil
+}
+
+// exitSignal always returns an empty string as Windows processes are not terminated by signals
+func exitSignal(state *os.ProcessState) string {
+	return ""
+}
@@ -0,0 +1,108 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Limits reported in Result.LimitExceeded
+const (
+	LimitMemory       = "memory"
+	LimitCPUTime      = "cpu_time"
+	LimitMaxProcesses = "max_processes"
+	LimitWallClock    = "wall_clock"
+)
+
+// DefaultResourceLimitsKey is the key in the resource limits configuration
+// whose limits apply to every tool without an entry of its own
+const DefaultResourceLimitsKey = "default"
+
+// ResourceLimits restricts the resources a command may use. Zero values
+// mean no limit.
+//
+// Memory and process limits are enforced with a cgroup v2 per run when
+// CgroupRoot is set, otherwise with RLIMIT_AS and RLIMIT_NPROC. CPU time,
+// open files and nice level are applied with setrlimit/setpriority before
+// the command is executed. RLIMIT_NPROC counts all processes of the user
+// and is ignored for root, so MaxProcesses is only a per-run limit with a
+// cgroup.
+type ResourceLimits struct {
+	MemoryMB         int64  `json:"memory_mb,omitempty"`
+	CPUSeconds       uint64 `json:"cpu_seconds,omitempty"`
+	Nice             int    `json:"nice,omitempty"`
+	MaxProcesses     uint64 `json:"max_processes,omitempty"`
+	MaxOpenFiles     uint64 `json:"max_open_files,omitempty"`
+	WallClockSeconds int    `json:"wall_clock_seconds,omitempty"`
+}
+
+var (
+	// ToolResourceLimits maps tool names (binary names) to their limits. The
+	// entry DefaultResourceLimitsKey applies to all tools.
+	ToolResourceLimits = map[string]ResourceLimits{}
+
+	// CgroupRoot is a delegated cgroup v2 directory below which a cgroup is
+	// created for every run. Memory and process limits fall back to rlimits
+	// when it is empty.
+	CgroupRoot = ""
+)
+
+// LoadResourceLimits reads per-tool resource limits from a JSON file of the form
+// {"default": {"memory_mb": 2048}, "john": {"cpu_seconds": 3600, "nice": 10}}
+func LoadResourceLimits(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read resource limits: %w", err)
+	}
+	limits := map[string]ResourceLimits{}
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return fmt.Errorf("failed to parse resource limits: %w", err)
+	}
+	ToolResourceLimits = limits
+	return nil
+}
+
+// SetCgroupRoot sets the cgroup v2 directory used for per-run cgroups
+func SetCgroupRoot(dir string) {
+	CgroupRoot = dir
+}
+
+// ResourceLimitsFor returns the limits of tool, with the default limits
+// filling in whatever the tool does not set itself
+func ResourceLimitsFor(tool string) ResourceLimits {
+	limits := ToolResourceLimits[DefaultResourceLimitsKey]
+	override, ok := ToolResourceLimits[tool]
+	if !ok {
+		return limits
+	}
+	if override.MemoryMB != 0 {
+		limits.MemoryMB = override.MemoryMB
+	}
+	if override.CPUSeconds != 0 {
+		limits.CPUSeconds = override.CPUSeconds
+	}
+	if override.Nice != 0 {
+		limits.Nice = override.Nice
+	}
+	if override.MaxProcesses != 0 {
+		limits.MaxProcesses = override.MaxProcesses
+	}
+	if override.MaxOpenFiles != 0 {
+		limits.MaxOpenFiles = override.MaxOpenFiles
+	}
+	if override.WallClockSeconds != 0 {
+		limits.WallClockSeconds = override.WallClockSeconds
+	}
+	return limits
+}
+
+// wallClock returns the wall clock limit, 0 if there is none
+func (l ResourceLimits) wallClock() time.Duration {
+	return time.Duration(l.WallClockSeconds) * time.Second
+}
+
+// isZero reports whether no limit is set
+func (l ResourceLimits) isZero() bool {
+	return l == ResourceLimits{}
+}
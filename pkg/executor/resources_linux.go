@@ -0,0 +1,245 @@
+//go:build linux
+// +build linux
+
+package executor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// cpuKillGrace is how many CPU seconds a process gets after SIGXCPU before
+// the kernel kills it
+const cpuKillGrace = 5
+
+// rlimitsEnv holds the limits for the server binary re-executed as a helper
+// that applies them to itself and then execs the command. The limits are in
+// place before the command runs, so that nothing it forks escapes them.
+const rlimitsEnv = "MCP_KALI_SERVER_RLIMITS"
+
+// rlimits are the limits the helper applies before it execs the command
+type rlimits struct {
+	ResourceLimits
+	// Cgroup is set when memory and processes are limited by a cgroup
+	Cgroup bool `json:"cgroup,omitempty"`
+}
+
+func init() {
+	if spec, ok := os.LookupEnv(rlimitsEnv); ok {
+		execWithRlimits(spec)
+	}
+}
+
+// execWithRlimits is the helper: it applies the limits in spec and replaces
+// itself with the command given as its arguments. It never returns.
+func execWithRlimits(spec string) {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "resource limit helper: no command")
+		os.Exit(127)
+	}
+	var limits rlimits
+	if err := json.Unmarshal([]byte(spec), &limits); err != nil {
+		fmt.Fprintf(os.Stderr, "resource limit helper: %v\n", err)
+		os.Exit(127)
+	}
+	path, argv := os.Args[1], os.Args[2:]
+	env := make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, rlimitsEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+
+	if limits.CPUSeconds > 0 {
+		setrlimit(unix.RLIMIT_CPU, limits.CPUSeconds, limits.CPUSeconds+cpuKillGrace)
+	}
+	if limits.MaxOpenFiles > 0 {
+		setrlimit(unix.RLIMIT_NOFILE, limits.MaxOpenFiles, limits.MaxOpenFiles)
+	}
+	if !limits.Cgroup && limits.MaxProcesses > 0 {
+		setrlimit(unix.RLIMIT_NPROC, limits.MaxProcesses, limits.MaxProcesses)
+	}
+	if limits.Nice != 0 {
+		if err := unix.Setpriority(unix.PRIO_PROCESS, 0, limits.Nice); err != nil {
+			fmt.Fprintf(os.Stderr, "resource limit helper: failed to set nice level %d: %v\n", limits.Nice, err)
+		}
+	}
+	// The address space limit comes last, as the helper itself may not be
+	// able to allocate once it is set
+	if !limits.Cgroup && limits.MemoryMB > 0 {
+		memory := uint64(limits.MemoryMB) << 20
+		setrlimit(unix.RLIMIT_AS, memory, memory)
+	}
+
+	err := syscall.Exec(path, argv, env)
+	fmt.Fprintf(os.Stderr, "resource limit helper: %v\n", err)
+	os.Exit(127)
+}
+
+// setrlimit sets a resource limit of the helper, reporting failures on the
+// command's stderr. syscall.Setrlimit is used so that the Go runtime does not
+// restore its own open files limit on exec.
+func setrlimit(resource int, soft, hard uint64) {
+	if err := syscall.Setrlimit(resource, &syscall.Rlimit{Cur: soft, Max: hard}); err != nil {
+		fmt.Fprintf(os.Stderr, "resource limit helper: failed to set resource limit %d: %v\n", resource, err)
+	}
+}
+
+// resourceControl applies the resource limits of one run
+type resourceControl struct {
+	limits    ResourceLimits
+	cgroupDir string
+	cgroup    *os.File
+}
+
+// prepareResources sets up the limits before the command starts. With a
+// cgroup the process is started directly inside it, and the rlimits are
+// applied by the helper before it execs the command, so nothing the command
+// spawns can escape the limits.
+func prepareResources(cmd *exec.Cmd, runID string, limits ResourceLimits) *resourceControl {
+	rc := &resourceControl{limits: limits}
+	if CgroupRoot != "" && (limits.MemoryMB > 0 || limits.MaxProcesses > 0) {
+		if err := rc.createCgroup(runID); err != nil {
+			log.Printf("Failed to create cgroup, falling back to rlimits: %v", err)
+			rc.removeCgroup()
+		} else {
+			if cmd.SysProcAttr == nil {
+				cmd.SysProcAttr = &syscall.SysProcAttr{}
+			}
+			cmd.SysProcAttr.UseCgroupFD = true
+			cmd.SysProcAttr.CgroupFD = int(rc.cgroup.Fd())
+		}
+	}
+	rc.wrap(cmd)
+	return rc
+}
+
+// wrap runs cmd through the rlimit helper if it has limits the cgroup does
+// not enforce
+func (rc *resourceControl) wrap(cmd *exec.Cmd) {
+	limits := rlimits{ResourceLimits: rc.limits, Cgroup: rc.cgroupDir != ""}
+	limits.WallClockSeconds = 0
+	if limits.Cgroup {
+		limits.MemoryMB, limits.MaxProcesses = 0, 0
+	}
+	if limits.isZero() || cmd.Err != nil {
+		return
+	}
+	self, err := os.Executable()
+	if err != nil {
+		log.Printf("Failed to apply resource limits: %v", err)
+		return
+	}
+	spec, err := json.Marshal(limits)
+	if err != nil {
+		log.Printf("Failed to apply resource limits: %v", err)
+		return
+	}
+	cmd.Env = append(cmd.Environ(), rlimitsEnv+"="+string(spec))
+	cmd.Args = append([]string{filepath.Base(cmd.Path), cmd.Path}, cmd.Args...)
+	cmd.Path = self
+}
+
+// createCgroup creates the cgroup of the run and writes its limits
+func (rc *resourceControl) createCgroup(runID string) error {
+	// Enabling the controllers fails if they are already enabled or the
+	// root is not delegated to us; writing the limits below tells which
+	_ = os.WriteFile(filepath.Join(CgroupRoot, "cgroup.subtree_control"), []byte("+memory +pids"), 0)
+
+	dir := filepath.Join(CgroupRoot, "run-"+runID)
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return err
+	}
+	rc.cgroupDir = dir
+
+	if rc.limits.MemoryMB > 0 {
+		memory := strconv.FormatInt(rc.limits.MemoryMB<<20, 10)
+		if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(memory), 0); err != nil {
+			return fmt.Errorf("failed to set memory limit: %w", err)
+		}
+		// Do not let the limit be dodged by swapping
+		_ = os.WriteFile(filepath.Join(dir, "memory.swap.max"), []byte("0"), 0)
+	}
+	if rc.limits.MaxProcesses > 0 {
+		pids := strconv.FormatUint(rc.limits.MaxProcesses, 10)
+		if err := os.WriteFile(filepath.Join(dir, "pids.max"), []byte(pids), 0); err != nil {
+			return fmt.Errorf("failed to set process limit: %w", err)
+		}
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	rc.cgroup = f
+	return nil
+}
+
+// finish removes the cgroup of the run and returns the limit that stopped
+// the command, if any
+func (rc *resourceControl) finish(state *os.ProcessState) string {
+	exceeded := ""
+	if rc.cgroupDir != "" {
+		if eventCount(filepath.Join(rc.cgroupDir, "memory.events"), "oom_kill") > 0 {
+			exceeded = LimitMemory
+		} else if eventCount(filepath.Join(rc.cgroupDir, "pids.events"), "max") > 0 {
+			exceeded = LimitMaxProcesses
+		}
+		rc.removeCgroup()
+	}
+
+	if exceeded == "" && rc.limits.CPUSeconds > 0 && state != nil {
+		status, ok := state.Sys().(syscall.WaitStatus)
+		used := state.UserTime() + state.SystemTime()
+		if ok && status.Signaled() && (status.Signal() == syscall.SIGXCPU ||
+			(status.Signal() == syscall.SIGKILL && used.Seconds() >= float64(rc.limits.CPUSeconds))) {
+			exceeded = LimitCPUTime
+		}
+	}
+	return exceeded
+}
+
+// removeCgroup kills whatever is left in the cgroup and removes it
+func (rc *resourceControl) removeCgroup() {
+	if rc.cgroup != nil {
+		rc.cgroup.Close()
+		rc.cgroup = nil
+	}
+	if rc.cgroupDir == "" {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(rc.cgroupDir, "cgroup.kill"), []byte("1"), 0)
+	if err := os.Remove(rc.cgroupDir); err != nil {
+		log.Printf("Failed to remove cgroup %s: %v", rc.cgroupDir, err)
+	}
+	rc.cgroupDir = ""
+}
+
+// eventCount returns the value of key in a cgroup events file
+func eventCount(path, key string) int64 {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == key {
+			count, _ := strconv.ParseInt(fields[1], 10, 64)
+			return count
+		}
+	}
+	return 0
+}
@@ -0,0 +1,28 @@
+//go:build !linux
+// +build !linux
+
+package executor
+
+import (
+	"log"
+	"os"
+	"os/exec"
+)
+
+// resourceControl applies the resource limits of one run. Only the wall
+// clock limit is supported on this platform.
+type resourceControl struct{}
+
+// prepareResources warns that the limits cannot be enforced
+func prepareResources(cmd *exec.Cmd, runID string, limits ResourceLimits) *resourceControl {
+	limits.WallClockSeconds = 0
+	if !limits.isZero() {
+		log.Printf("Resource limits other than wall_clock_seconds are only supported on Linux")
+	}
+	return &resourceControl{}
+}
+
+// finish is a no-op on this platform
+func (rc *resourceControl) finish(state *os.ProcessState) string {
+	return ""
+}
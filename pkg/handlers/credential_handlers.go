@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/credentials"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/tools"
+	"github.com/gin-gonic/gin"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// CredentialList is the output of credentials_list
+type CredentialList struct {
+	Credentials []credentials.Credential `json:"credentials"`
+}
+
+// CredentialExport is the output of credentials_export
+type CredentialExport struct {
+	Format string `json:"format"`
+	Data   string `json:"data"`
+}
+
+// CredentialsListHandler handles requests to list discovered credentials
+func CredentialsListHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[credentials.ListParams]) (*mcp.CallToolResultFor[CredentialList], error) {
+	list := credentials.DefaultStore.List(credentials.Filter{
+		Host:    params.Arguments.Host,
+		Service: params.Arguments.Service,
+		Source:  params.Arguments.Source,
+	})
+	return jsonToolResult(CredentialList{Credentials: list})
+}
+
+// CredentialsExportHandler handles requests to export discovered credentials
+func CredentialsExportHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[credentials.ExportParams]) (*mcp.CallToolResultFor[CredentialExport], error) {
+	filter := credentials.Filter{
+		Host:    params.Arguments.Host,
+		Service: params.Arguments.Service,
+		Source:  params.Arguments.Source,
+	}
+	export, err := credentials.DefaultStore.Export(filter, params.Arguments.Format)
+	if err != nil {
+		return errorToolResult[CredentialExport](err), nil
+	}
+	format := params.Arguments.Format
+	if format == "" {
+		format = credentials.FormatJSON
+	}
+	return &mcp.CallToolResultFor[CredentialExport]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: export},
+		},
+		StructuredContent: CredentialExport{Format: format, Data: export},
+	}, nil
+}
+
+// registerCredentialTools adds the credential store tools to server,
+// loading the credentials found before a restart
+func registerCredentialTools(server *mcp.Server) {
+	if err := credentials.DefaultStore.Load(); err != nil {
+		log.Printf("Failed to load credentials: %v", err)
+	}
+
+	mcp.AddTool(server, withOutputSchema[CredentialList](describeTool(tools.Definition{
+		Name:        "credentials_list",
+		Title:       "List Credentials",
+		Description: "List credentials found by hydra_attack and john_crack, optionally filtered by host, service or source",
+		ReadOnly:    true,
+		Idempotent:  true,
+		Risk:        tools.RiskLow,
+	}, inputSchema[credentials.ListParams]())), CredentialsListHandler)
+
+	mcp.AddTool(server, withOutputSchema[CredentialExport](describeTool(tools.Definition{
+		Name:        "credentials_export",
+		Title:       "Export Credentials",
+		Description: "Export found credentials as json, csv or userpass (user:password lines usable with hydra -C)",
+		ReadOnly:    true,
+		Idempotent:  true,
+		Risk:        tools.RiskLow,
+	}, inputSchema[credentials.ExportParams]())), CredentialsExportHandler)
+}
+
+// credentialFilter reads the credential filter from the query string
+func credentialFilter(c *gin.Context) credentials.Filter {
+	return credentials.Filter{
+		Host:    c.Query("host"),
+		Service: c.Query("service"),
+		Source:  c.Query("source"),
+	}
+}
+
+func ListCredentialsHandler(c *gin.Context) {
+	list := credentials.DefaultStore.List(credentialFilter(c))
+	c.JSON(http.StatusOK, gin.H{"credentials": list})
+}
+
+func ExportCredentialsHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", credentials.FormatJSON)
+	export, err := credentials.DefaultStore.Export(credentialFilter(c), format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "text/plain; charset=utf-8"
+	switch format {
+	case credentials.FormatJSON:
+		contentType = "application/json; charset=utf-8"
+	case credentials.FormatCSV:
+		contentType = "text/csv; charset=utf-8"
+	}
+	c.Data(http.StatusOK, contentType, []byte(export))
+}
@@ -1,12 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
-	"strings"
+	"os/exec"
 
 	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/tools"
 	"github.com/gin-gonic/gin"
 )
 
@@ -20,26 +21,54 @@ func getStringParam(data map[string]interface{}, key string, defaultValue string
 	return defaultValue
 }
 
+// Helper function to safely get an integer from map
+func getIntParam(data map[string]interface{}, key string, defaultValue int) int {
+	if val, ok := data[key]; ok {
+		if fVal, ok := val.(float64); ok {
+			return int(fVal)
+		}
+	}
+	return defaultValue
+}
+
+// toolErrorStatus returns the HTTP status code for an error returned by a tool
+func toolErrorStatus(err error) int {
+	if errors.Is(err, executor.ErrQueueFull) {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// respondWithRun records the run of tool with params and responds with its
+// result, or with the error that kept it from running
+func respondWithRun(c *gin.Context, tool string, params any, result *tools.ToolResult, err error) {
+	if err != nil {
+		c.JSON(toolErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	recordRun(tool, params, result)
+	c.JSON(http.StatusOK, result)
+}
+
 func GenericCommandHandler(c *gin.Context) {
-	var data map[string]string
+	var data map[string]interface{}
 	if err := c.BindJSON(&data); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request."})
 		return
 	}
 
-	command, ok := data["command"]
-	if !ok || command == "" {
+	command := getStringParam(data, "command", "")
+	if command == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Command parameter is required"})
 		return
 	}
 
-	result, err := executor.ExecuteCommand(command)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	params := tools.GenericCommandParams{
+		Command:        command,
+		TimeoutSeconds: getIntParam(data, "timeout_seconds", 0),
 	}
-
-	c.JSON(http.StatusOK, result)
+	result, err := tools.ExecuteGenericCommand(c.Request.Context(), params)
+	respondWithRun(c, "execute_command", params, result, err)
 }
 
 func NmapHandler(c *gin.Context) {
@@ -55,26 +84,15 @@ func NmapHandler(c *gin.Context) {
 		return
 	}
 
-	scanType := getStringParam(data, "scan_type", "-sCV")
-	ports := getStringParam(data, "ports", "")
-	additionalArgs := getStringParam(data, "additional_args", "-T4 -Pn")
-
-	command := fmt.Sprintf("nmap %s", scanType)
-	if ports != "" {
-		command += fmt.Sprintf(" -p %s", ports)
-	}
-	if additionalArgs != "" {
-		command += fmt.Sprintf(" %s", additionalArgs)
-	}
-	command += fmt.Sprintf(" %s", target)
-
-	result, err := executor.ExecuteCommand(command)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	params := tools.NmapParams{
+		Target:         target,
+		ScanType:       getStringParam(data, "scan_type", "-sCV"),
+		Ports:          getStringParam(data, "ports", ""),
+		AdditionalArgs: getStringParam(data, "additional_args", "-T4 -Pn"),
+		TimeoutSeconds: getIntParam(data, "timeout_seconds", 0),
 	}
-
-	c.JSON(http.StatusOK, result)
+	result, err := tools.NmapScan(c.Request.Context(), params)
+	respondWithRun(c, "nmap_scan", params, result, err)
 }
 
 func GobusterHandler(c *gin.Context) {
@@ -98,21 +116,15 @@ func GobusterHandler(c *gin.Context) {
 		return
 	}
 
-	wordlist := getStringParam(data, "wordlist", "/usr/share/wordlists/dirb/common.txt")
-	additionalArgs := getStringParam(data, "additional_args", "")
-
-	command := fmt.Sprintf("gobuster %s -u %s -w %s", mode, url, wordlist)
-	if additionalArgs != "" {
-		command += fmt.Sprintf(" %s", additionalArgs)
-	}
-
-	result, err := executor.ExecuteCommand(command)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	params := tools.GobusterParams{
+		URL:            url,
+		Mode:           mode,
+		Wordlist:       getStringParam(data, "wordlist", "/usr/share/wordlists/dirb/common.txt"),
+		AdditionalArgs: getStringParam(data, "additional_args", ""),
+		TimeoutSeconds: getIntParam(data, "timeout_seconds", 0),
 	}
-
-	c.JSON(http.StatusOK, result)
+	result, err := tools.GobusterScan(c.Request.Context(), params)
+	respondWithRun(c, "gobuster_scan", params, result, err)
 }
 
 func DirbHandler(c *gin.Context) {
@@ -128,21 +140,14 @@ func DirbHandler(c *gin.Context) {
 		return
 	}
 
-	wordlist := getStringParam(data, "wordlist", "/usr/share/wordlists/dirb/common.txt")
-	additionalArgs := getStringParam(data, "additional_args", "")
-
-	command := fmt.Sprintf("dirb %s %s", url, wordlist)
-	if additionalArgs != "" {
-		command += fmt.Sprintf(" %s", additionalArgs)
+	params := tools.DirbParams{
+		URL:            url,
+		Wordlist:       getStringParam(data, "wordlist", "/usr/share/wordlists/dirb/common.txt"),
+		AdditionalArgs: getStringParam(data, "additional_args", ""),
+		TimeoutSeconds: getIntParam(data, "timeout_seconds", 0),
 	}
-
-	result, err := executor.ExecuteCommand(command)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, result)
+	result, err := tools.DirbScan(c.Request.Context(), params)
+	respondWithRun(c, "dirb_scan", params, result, err)
 }
 
 func NiktoHandler(c *gin.Context) {
@@ -158,20 +163,13 @@ func NiktoHandler(c *gin.Context) {
 		return
 	}
 
-	additionalArgs := getStringParam(data, "additional_args", "")
-
-	command := fmt.Sprintf("nikto -h %s", target)
-	if additionalArgs != "" {
-		command += fmt.Sprintf(" %s", additionalArgs)
+	params := tools.NiktoParams{
+		Target:         target,
+		AdditionalArgs: getStringParam(data, "additional_args", ""),
+		TimeoutSeconds: getIntParam(data, "timeout_seconds", 0),
 	}
-
-	result, err := executor.ExecuteCommand(command)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, result)
+	result, err := tools.NiktoScan(c.Request.Context(), params)
+	respondWithRun(c, "nikto_scan", params, result, err)
 }
 
 func SqlmapHandler(c *gin.Context) {
@@ -187,24 +185,38 @@ func SqlmapHandler(c *gin.Context) {
 		return
 	}
 
-	postData := getStringParam(data, "data", "")
-	additionalArgs := getStringParam(data, "additional_args", "")
-
-	command := fmt.Sprintf("sqlmap -u %s --batch", url)
-	if postData != "" {
-		command += fmt.Sprintf(" --data=\"%s\"", postData)
+	params := tools.SqlmapParams{
+		URL:            url,
+		Data:           getStringParam(data, "data", ""),
+		AdditionalArgs: getStringParam(data, "additional_args", ""),
+		TimeoutSeconds: getIntParam(data, "timeout_seconds", 0),
 	}
-	if additionalArgs != "" {
-		command += fmt.Sprintf(" %s", additionalArgs)
+	result, err := tools.SqlmapScan(c.Request.Context(), params)
+	respondWithRun(c, "sqlmap_scan", params, result, err)
+}
+
+func ResumeSqlmapHandler(c *gin.Context) {
+	var data map[string]interface{}
+	if err := c.BindJSON(&data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request."})
+		return
 	}
 
-	result, err := executor.ExecuteCommand(command)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	jobID := getStringParam(data, "job_id", "")
+	runID := getStringParam(data, "run_id", "")
+	if jobID == "" && runID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "job_id or run_id parameter is required"})
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	params := tools.SqlmapResumeParams{
+		JobID:          jobID,
+		RunID:          runID,
+		AdditionalArgs: getStringParam(data, "additional_args", ""),
+		TimeoutSeconds: getIntParam(data, "timeout_seconds", 0),
+	}
+	result, err := tools.SqlmapResume(c.Request.Context(), params)
+	respondWithRun(c, "sqlmap_resume", params, result, err)
 }
 
 func MetasploitHandler(c *gin.Context) {
@@ -220,33 +232,15 @@ func MetasploitHandler(c *gin.Context) {
 		return
 	}
 
-	options := data["options"].(map[string]interface{})
-
-	// Format options
-	var optionsStr strings.Builder
-	for key, value := range options {
-		optionsStr.WriteString(fmt.Sprintf(" set %s %v\n", key, value))
-	}
-
-	resourceContent := fmt.Sprintf("use %s\n%s exploit\n", module, optionsStr.String())
-	tempFile, err := os.CreateTemp("", "mcp_msf_resource.rc")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create temporary file."})
-		return
-	}
-	defer os.Remove(tempFile.Name())
-
-	tempFile.WriteString(resourceContent)
-	tempFile.Close()
+	options, _ := data["options"].(map[string]interface{})
 
-	command := fmt.Sprintf("msfconsole -q -r %s", tempFile.Name())
-	result, err := executor.ExecuteCommand(command)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	params := tools.MetasploitParams{
+		Module:         module,
+		Options:        options,
+		TimeoutSeconds: getIntParam(data, "timeout_seconds", 0),
 	}
-
-	c.JSON(http.StatusOK, result)
+	result, err := tools.MetasploitRun(c.Request.Context(), params)
+	respondWithRun(c, "metasploit_run", params, result, err)
 }
 
 func HydraHandler(c *gin.Context) {
@@ -283,35 +277,18 @@ func HydraHandler(c *gin.Context) {
 		return
 	}
 
-	additionalArgs := getStringParam(data, "additional_args", "")
-
-	command := fmt.Sprintf("hydra -t 4")
-
-	if username != "" {
-		command += fmt.Sprintf(" -l %s", username)
-	} else {
-		command += fmt.Sprintf(" -L %s", usernameFile)
-	}
-
-	if password != "" {
-		command += fmt.Sprintf(" -p %s", password)
-	} else {
-		command += fmt.Sprintf(" -P %s", passwordFile)
-	}
-
-	if additionalArgs != "" {
-		command += fmt.Sprintf(" %s", additionalArgs)
+	params := tools.HydraParams{
+		Target:         target,
+		Service:        service,
+		Username:       username,
+		UsernameFile:   usernameFile,
+		Password:       password,
+		PasswordFile:   passwordFile,
+		AdditionalArgs: getStringParam(data, "additional_args", ""),
+		TimeoutSeconds: getIntParam(data, "timeout_seconds", 0),
 	}
-
-	command += fmt.Sprintf(" %s %s", target, service)
-
-	result, err := executor.ExecuteCommand(command)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, result)
+	result, err := tools.HydraAttack(c.Request.Context(), params)
+	respondWithRun(c, "hydra_attack", params, result, err)
 }
 
 func JohnHandler(c *gin.Context) {
@@ -327,32 +304,15 @@ func JohnHandler(c *gin.Context) {
 		return
 	}
 
-	wordlist := getStringParam(data, "wordlist", "/usr/share/wordlists/rockyou.txt")
-	formatType := getStringParam(data, "format", "")
-	additionalArgs := getStringParam(data, "additional_args", "")
-
-	command := fmt.Sprintf("john")
-
-	if formatType != "" {
-		command += fmt.Sprintf(" --format=%s", formatType)
-	}
-
-	if wordlist != "" {
-		command += fmt.Sprintf(" --wordlist=%s", wordlist)
-	}
-
-	if additionalArgs != "" {
-		command += fmt.Sprintf(" %s", additionalArgs)
-	}
-
-	command += fmt.Sprintf(" %s", hashFile)
-	result, err := executor.ExecuteCommand(command)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	params := tools.JohnParams{
+		HashFile:       hashFile,
+		Wordlist:       getStringParam(data, "wordlist", "/usr/share/wordlists/rockyou.txt"),
+		Format:         getStringParam(data, "format", ""),
+		AdditionalArgs: getStringParam(data, "additional_args", ""),
+		TimeoutSeconds: getIntParam(data, "timeout_seconds", 0),
 	}
-
-	c.JSON(http.StatusOK, result)
+	result, err := tools.JohnCrack(c.Request.Context(), params)
+	respondWithRun(c, "john_crack", params, result, err)
 }
 
 func WpscanHandler(c *gin.Context) {
@@ -368,20 +328,14 @@ func WpscanHandler(c *gin.Context) {
 		return
 	}
 
-	additionalArgs := getStringParam(data, "additional_args", "")
-
-	command := fmt.Sprintf("wpscan --url %s", url)
-	if additionalArgs != "" {
-		command += fmt.Sprintf(" %s", additionalArgs)
+	params := tools.WpscanParams{
+		URL:            url,
+		AdditionalArgs: getStringParam(data, "additional_args", ""),
+		MinSeverity:    getStringParam(data, "min_severity", ""),
+		TimeoutSeconds: getIntParam(data, "timeout_seconds", 0),
 	}
-
-	result, err := executor.ExecuteCommand(command)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, result)
+	result, err := tools.WpscanAnalyze(c.Request.Context(), params)
+	respondWithRun(c, "wpscan_analyze", params, result, err)
 }
 
 func Enum4linuxHandler(c *gin.Context) {
@@ -397,16 +351,13 @@ func Enum4linuxHandler(c *gin.Context) {
 		return
 	}
 
-	additionalArgs := getStringParam(data, "additional_args", "-a")
-
-	command := fmt.Sprintf("enum4linux %s %s", additionalArgs, target)
-	result, err := executor.ExecuteCommand(command)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	params := tools.Enum4linuxParams{
+		Target:         target,
+		AdditionalArgs: getStringParam(data, "additional_args", "-a"),
+		TimeoutSeconds: getIntParam(data, "timeout_seconds", 0),
 	}
-
-	c.JSON(http.StatusOK, result)
+	result, err := tools.Enum4linuxScan(c.Request.Context(), params)
+	respondWithRun(c, "enum4linux_scan", params, result, err)
 }
 
 func Sublist3rHandler(c *gin.Context) {
@@ -449,37 +400,27 @@ func Sublist3rHandler(c *gin.Context) {
 			verbose = bVal
 		}
 	}
-	additionalArgs := getStringParam(data, "additional_args", "")
-
-	command := fmt.Sprintf("sublist3r -d %s", domain)
-	if bruteForce {
-		command += " -b"
-	}
-	if ports != "" {
-		command += fmt.Sprintf(" -p %s", ports)
-	}
-	if threads > 0 {
-		command += fmt.Sprintf(" -t %d", threads)
-	} else {
-		command += " -t 10"
-	}
-	if engines != "" {
-		command += fmt.Sprintf(" -e %s", engines)
-	}
-	if verbose {
-		command += " -v"
-	}
-	if additionalArgs != "" {
-		command += fmt.Sprintf(" %s", additionalArgs)
-	}
-
-	result, err := executor.ExecuteCommand(command)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	resolve := false
+	if val, ok := data["resolve"]; ok {
+		if bVal, ok := val.(bool); ok {
+			resolve = bVal
+		}
 	}
+	additionalArgs := getStringParam(data, "additional_args", "")
 
-	c.JSON(http.StatusOK, result)
+	params := tools.Sublist3rParams{
+		Domain:         domain,
+		BruteForce:     bruteForce,
+		Ports:          ports,
+		Threads:        threads,
+		Engines:        engines,
+		Verbose:        verbose,
+		AdditionalArgs: additionalArgs,
+		Resolve:        resolve,
+		TimeoutSeconds: getIntParam(data, "timeout_seconds", 0),
+	}
+	result, err := tools.Sublist3rScan(c.Request.Context(), params)
+	respondWithRun(c, "sublist3r_scan", params, result, err)
 }
 
 func HealthCheckHandler(c *gin.Context) {
@@ -488,11 +429,11 @@ essentialTools := []string{"nmap", "gobuster", "dirb", "nikto"}
 
 toolsStatus := map[string]bool{}
 
+	// Look the tools up directly so that the health check is not queued
+	// behind running scans
 	for _, tool := range essentialTools {
-		command := fmt.Sprintf("which %s", tool)
-		result, _ := executor.ExecuteCommand(command)
-		success := strings.TrimSpace(result.Stdout) != ""
-		toolsStatus[tool] = success
+		_, err := exec.LookPath(tool)
+		toolsStatus[tool] = err == nil
 	}
 
 	allEssentialToolsAvailable := true
@@ -503,10 +444,14 @@ toolsStatus := map[string]bool{}
 		}
 	}
 
+	running, queued := executor.DefaultLimiter.Stats()
+
 	c.JSON(http.StatusOK, gin.H{
 		"status": "healthy",
 		"message": "Kali Linux Tools API Server is running",
 		"tools_status": toolsStatus,
 		"all_essential_tools_available": allEssentialToolsAvailable,
+		"running_commands": running,
+		"queued_commands": queued,
 	})
 }
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/jobs"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/tools"
+	"github.com/gin-gonic/gin"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// JobList is the output of job_list
+type JobList struct {
+	Jobs []*jobs.Job `json:"jobs"`
+}
+
+// JobStartHandler handles requests to run a tool in the background
+func JobStartHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[jobs.StartParams]) (*mcp.CallToolResultFor[jobs.Job], error) {
+	arguments, err := json.Marshal(params.Arguments.Arguments)
+	if err != nil {
+		return errorToolResult[jobs.Job](fmt.Errorf("invalid arguments: %w", err)), nil
+	}
+	if def, ok := tools.Lookup(params.Arguments.Tool); ok {
+		if err := checkConfirmation(def, confirmedCall(params.Meta)); err != nil {
+			return errorToolResult[jobs.Job](confirmationHint(err)), nil
+		}
+	}
+	job, err := jobs.DefaultManager.Start(params.Arguments.Tool, arguments, params.Arguments.Priority)
+	if err != nil {
+		return errorToolResult[jobs.Job](err), nil
+	}
+	return jsonToolResult(*job)
+}
+
+// JobStatusHandler handles job status requests
+func JobStatusHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[jobs.JobParams]) (*mcp.CallToolResultFor[jobs.Job], error) {
+	job, err := jobs.DefaultManager.Get(params.Arguments.JobID)
+	if err != nil {
+		return errorToolResult[jobs.Job](err), nil
+	}
+	return jsonToolResult(*job)
+}
+
+// JobOutputHandler handles job output requests
+func JobOutputHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[jobs.OutputParams]) (*mcp.CallToolResultFor[jobs.Output], error) {
+	output, err := jobs.DefaultManager.Output(params.Arguments.JobID, params.Arguments.TailLines)
+	if err != nil {
+		return errorToolResult[jobs.Output](err), nil
+	}
+	return &mcp.CallToolResultFor[jobs.Output]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: fmt.Sprintf("Job %s (%s):\n%s", output.JobID, output.Status, output.Output)},
+		},
+		StructuredContent: *output,
+	}, nil
+}
+
+// JobCancelHandler handles job cancellation requests
+func JobCancelHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[jobs.JobParams]) (*mcp.CallToolResultFor[jobs.Job], error) {
+	job, err := jobs.DefaultManager.Cancel(params.Arguments.JobID)
+	if err != nil {
+		return errorToolResult[jobs.Job](err), nil
+	}
+	return jsonToolResult(*job)
+}
+
+// JobListHandler handles job listing requests
+func JobListHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[jobs.ListParams]) (*mcp.CallToolResultFor[JobList], error) {
+	list := jobs.DefaultManager.List()
+	// Leave out the potentially large results, job_status returns them
+	for _, job := range list {
+		job.Result = nil
+	}
+	return jsonToolResult(JobList{Jobs: list})
+}
+
+// registerJobTools adds the job management tools to server
+func registerJobTools(server *mcp.Server) {
+	// job_start takes the risk of the tool it runs, which is checked when
+	// the job is started
+	mcp.AddTool(server, withOutputSchema[jobs.Job](describeTool(tools.Definition{
+		Name:        "job_start",
+		Title:       "Start Background Job",
+		Description: "Run any tool in the background and return a job ID to poll with job_status. Jobs with a higher priority are started first when the execution queue is busy",
+		Destructive: true,
+		OpenWorld:   true,
+	}, inputSchema[jobs.StartParams]())), JobStartHandler)
+
+	mcp.AddTool(server, withOutputSchema[jobs.Job](describeTool(tools.Definition{
+		Name:        "job_status",
+		Title:       "Get Job Status",
+		Description: "Get the status and, once finished, the result of a background job",
+		ReadOnly:    true,
+		Idempotent:  true,
+		Risk:        tools.RiskLow,
+	}, inputSchema[jobs.JobParams]())), JobStatusHandler)
+
+	mcp.AddTool(server, withOutputSchema[jobs.Output](describeTool(tools.Definition{
+		Name:        "job_output",
+		Title:       "Get Job Output",
+		Description: "Get the output of a background job, optionally only the last lines",
+		ReadOnly:    true,
+		Idempotent:  true,
+		Risk:        tools.RiskLow,
+	}, inputSchema[jobs.OutputParams]())), JobOutputHandler)
+
+	mcp.AddTool(server, withOutputSchema[jobs.Job](describeTool(tools.Definition{
+		Name:        "job_cancel",
+		Title:       "Cancel Job",
+		Description: "Cancel a running background job",
+		Destructive: true,
+		Idempotent:  true,
+		Risk:        tools.RiskLow,
+	}, inputSchema[jobs.JobParams]())), JobCancelHandler)
+
+	mcp.AddTool(server, withOutputSchema[JobList](describeTool(tools.Definition{
+		Name:        "job_list",
+		Title:       "List Jobs",
+		Description: "List background jobs and their status",
+		ReadOnly:    true,
+		Idempotent:  true,
+		Risk:        tools.RiskLow,
+	}, inputSchema[jobs.ListParams]())), JobListHandler)
+}
+
+// jsonToolResult returns v as structured content and as indented JSON text
+// content for clients that do not read structured content
+func jsonToolResult[T any](v T) (*mcp.CallToolResultFor[T], error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.CallToolResultFor[T]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(data)},
+		},
+		StructuredContent: v,
+	}, nil
+}
+
+// errorToolResult reports err as a tool error rather than a protocol error,
+// so that the model can see and correct it, like toolCallResult does for
+// tool runs. Invalid arguments, unknown jobs and failures to start a job
+// are all reported this way.
+func errorToolResult[T any](err error) *mcp.CallToolResultFor[T] {
+	return &mcp.CallToolResultFor[T]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: "Error: " + err.Error()},
+		},
+		IsError: true,
+	}
+}
+
+func StartJobHandler(c *gin.Context) {
+	var params jobs.StartParams
+	if err := c.BindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request."})
+		return
+	}
+
+	if params.Tool == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tool parameter is required"})
+		return
+	}
+
+	arguments, err := json.Marshal(params.Arguments)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid arguments."})
+		return
+	}
+
+	if def, ok := tools.Lookup(params.Tool); ok && !confirmRequest(c, def) {
+		return
+	}
+
+	job, err := jobs.DefaultManager.Start(params.Tool, arguments, params.Priority)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func ListJobsHandler(c *gin.Context) {
+	list := jobs.DefaultManager.List()
+	for _, job := range list {
+		job.Result = nil
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": list})
+}
+
+func GetJobHandler(c *gin.Context) {
+	job, err := jobs.DefaultManager.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func GetJobOutputHandler(c *gin.Context) {
+	tail, _ := strconv.Atoi(c.DefaultQuery("tail", "0"))
+
+	output, err := jobs.DefaultManager.Output(c.Param("id"), tail)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func CancelJobHandler(c *gin.Context) {
+	if _, err := jobs.DefaultManager.Get(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := jobs.DefaultManager.Cancel(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
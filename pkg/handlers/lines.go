@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+)
+
+// maxLineLength caps the bytes kept of a line still waiting for its end.
+// Longer lines are passed on in pieces of this size, so that output without
+// newlines cannot grow the buffer without bound.
+const maxLineLength = 64 << 10
+
+// lineSplitter splits the output chunks of a run into lines, buffering the
+// end of each stream until its line is complete
+type lineSplitter struct {
+	// redraws makes a carriage return end a line, for consumers of
+	// progress that is redrawn with them. Otherwise only the text after
+	// the last carriage return of a line is kept, its latest state.
+	redraws bool
+	pending map[string][]byte
+}
+
+func newLineSplitter(redraws bool) *lineSplitter {
+	return &lineSplitter{redraws: redraws, pending: make(map[string][]byte)}
+}
+
+// split calls fn with each line completed by chunk, without its line end
+func (s *lineSplitter) split(chunk executor.Chunk, fn func(line []byte)) {
+	ends := "\n"
+	if s.redraws {
+		ends = "\r\n"
+	}
+	data := append(s.pending[chunk.Stream], chunk.Data...)
+	for {
+		i := bytes.IndexAny(data, ends)
+		if i < 0 {
+			break
+		}
+		line := data[:i]
+		if !s.redraws {
+			line = bytes.TrimRight(line, "\r")
+			if j := bytes.LastIndexByte(line, '\r'); j >= 0 {
+				line = line[j+1:]
+			}
+		}
+		fn(line)
+		data = data[i+1:]
+	}
+	if !s.redraws {
+		if i := bytes.LastIndexByte(data, '\r'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	for len(data) > maxLineLength {
+		// Cut at the start of a character, if there is one close by
+		n := maxLineLength
+		for n > maxLineLength-utf8.UTFMax && !utf8.RuneStart(data[n]) {
+			n--
+		}
+		if !utf8.RuneStart(data[n]) {
+			n = maxLineLength
+		}
+		fn(data[:n])
+		data = data[n:]
+	}
+	s.pending[chunk.Stream] = append([]byte(nil), data...)
+}
+
+// flush calls fn with the lines still waiting for their end that are not
+// blank, stdout first
+func (s *lineSplitter) flush(fn func(stream string, line []byte)) {
+	for _, stream := range []string{executor.StreamStdout, executor.StreamStderr} {
+		if line := s.pending[stream]; len(bytes.TrimSpace(line)) > 0 {
+			fn(stream, line)
+		}
+		delete(s.pending, stream)
+	}
+}
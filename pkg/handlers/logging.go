@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// logQueueSize is the number of log messages of a tool call waiting to
+	// be sent. Output arriving faster than the messages are sent is dropped
+	// beyond that, and a notice tells the client how many lines it lost.
+	logQueueSize = 256
+	// logRate caps the log messages a tool call sends per second
+	logRate = 200
+)
+
+// outputLine is the data of a log message carrying a line of tool output
+type outputLine struct {
+	Stream   string `json:"stream"`
+	Seq      uint64 `json:"seq,omitempty"`
+	Line     string `json:"line"`
+	Encoding string `json:"encoding,omitempty"` // "base64" if the line is not valid UTF-8
+}
+
+// outputLogger forwards the output of a tool call line by line as MCP log
+// messages, stdout at info and stderr at notice level. The session drops
+// messages below the level the client set with logging/setLevel.
+//
+// Messages are queued and sent by a goroutine of their own, rate limited to
+// logRate per second, so that a slow client never blocks the command's
+// output pipes.
+type outputLogger struct {
+	mu       sync.Mutex
+	ctx      context.Context
+	ss       *mcp.ServerSession
+	tool     string
+	splitter *lineSplitter
+	queue    chan *mcp.LoggingMessageParams
+	dropped  int
+	closed   bool
+	done     chan struct{}
+}
+
+// withLogging returns a context that forwards the output and queue
+// position of tool runs to the client as log messages, and a function that
+// sends the last lines if they did not end with a newline and waits until
+// every queued message was sent
+func withLogging(ctx context.Context, ss *mcp.ServerSession, tool string) (context.Context, func()) {
+	if ss == nil {
+		return ctx, func() {}
+	}
+	l := &outputLogger{
+		ctx:      ctx,
+		ss:       ss,
+		tool:     tool,
+		splitter: newLineSplitter(false),
+		queue:    make(chan *mcp.LoggingMessageParams, logQueueSize),
+		done:     make(chan struct{}),
+	}
+	go l.run()
+	ctx = executor.WithQueueHandler(ctx, func(position int) {
+		if position > 0 {
+			l.log("notice", fmt.Sprintf("Waiting at queue position %d", position))
+		}
+	})
+	return executor.WithOutputHandler(ctx, l.output), l.flush
+}
+
+// output queues the complete lines of a chunk of output. Progress output
+// redrawn with carriage returns only keeps its latest state.
+func (l *outputLogger) output(chunk executor.Chunk) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.splitter.split(chunk, func(line []byte) {
+		l.enqueue(l.outputMessage(chunk, line))
+	})
+}
+
+// flush queues the lines still waiting for a newline and waits for the
+// queue to be sent
+func (l *outputLogger) flush() {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return
+	}
+	l.splitter.flush(func(stream string, line []byte) {
+		l.enqueue(l.outputMessage(executor.Chunk{Stream: stream}, line))
+	})
+	if l.dropped > 0 {
+		l.queue <- l.droppedMessage()
+	}
+	l.closed = true
+	close(l.queue)
+	l.mu.Unlock()
+	<-l.done
+}
+
+// run sends the queued messages, dropping them once the tool call is over.
+// Up to a second's worth of messages go out at once, after which they are
+// spaced out to logRate per second.
+func (l *outputLogger) run() {
+	defer close(l.done)
+	due := time.Now()
+	for params := range l.queue {
+		if l.ctx.Err() != nil {
+			continue
+		}
+		now := time.Now()
+		if due.Before(now) {
+			due = now
+		}
+		if wait := due.Sub(now) - time.Second; wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-l.ctx.Done():
+				continue
+			}
+		}
+		due = due.Add(time.Second / logRate)
+		l.ss.Log(l.ctx, params)
+	}
+}
+
+// enqueue queues a message without blocking, counting it as dropped if
+// the queue is full. The caller holds mu.
+func (l *outputLogger) enqueue(params *mcp.LoggingMessageParams) {
+	if l.closed {
+		return
+	}
+	// Tell the client about dropped lines as soon as there is room again.
+	// Only callers holding mu add to the queue, so the room cannot shrink.
+	if l.dropped > 0 && cap(l.queue)-len(l.queue) >= 2 {
+		l.queue <- l.droppedMessage()
+	}
+	select {
+	case l.queue <- params:
+	default:
+		l.dropped++
+	}
+}
+
+// droppedMessage returns the notice about dropped lines and resets their
+// count. The caller holds mu.
+func (l *outputLogger) droppedMessage() *mcp.LoggingMessageParams {
+	message := fmt.Sprintf("%d lines of output were not logged as they arrived faster than they could be sent", l.dropped)
+	l.dropped = 0
+	return &mcp.LoggingMessageParams{Logger: l.tool, Level: "notice", Data: message}
+}
+
+// outputMessage returns the log message of a line of output
+func (l *outputLogger) outputMessage(chunk executor.Chunk, line []byte) *mcp.LoggingMessageParams {
+	level := mcp.LoggingLevel("info")
+	if chunk.Stream == executor.StreamStderr {
+		level = "notice"
+	}
+	text, encoding := executor.EncodeOutput(line)
+	if encoding == "" {
+		text = parsers.StripANSI(text)
+	}
+	return &mcp.LoggingMessageParams{
+		Logger: l.tool,
+		Level:  level,
+		Data: outputLine{
+			Stream:   chunk.Stream,
+			Seq:      chunk.Seq,
+			Line:     text,
+			Encoding: encoding,
+		},
+	}
+}
+
+// log queues a message about the tool call itself
+func (l *outputLogger) log(level mcp.LoggingLevel, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.enqueue(&mcp.LoggingMessageParams{Logger: l.tool, Level: level, Data: message})
+}
@@ -3,256 +3,252 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/ba0f3/MCP-Kali-Server/pkg/tools"
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // NmapScanHandler handles Nmap scan requests
-func NmapScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.NmapParams]) (*mcp.CallToolResultFor[any], error) {
-	result, err := tools.NmapScan(params.Arguments)
-	if err != nil {
-		return nil, err
-	}
-	return &mcp.CallToolResultFor[any]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: formatToolResult(result)},
-		},
-	}, nil
+func NmapScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.NmapParams]) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	return callTool(ctx, ss, params, "nmap_scan", tools.NmapScan)
 }
 
 // GobusterScanHandler handles Gobuster scan requests
-func GobusterScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.GobusterParams]) (*mcp.CallToolResultFor[any], error) {
-	result, err := tools.GobusterScan(params.Arguments)
-	if err != nil {
-		return nil, err
-	}
-	return &mcp.CallToolResultFor[any]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: formatToolResult(result)},
-		},
-	}, nil
+func GobusterScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.GobusterParams]) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	return callTool(ctx, ss, params, "gobuster_scan", tools.GobusterScan)
 }
 
 // DirbScanHandler handles Dirb scan requests
-func DirbScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.DirbParams]) (*mcp.CallToolResultFor[any], error) {
-	result, err := tools.DirbScan(params.Arguments)
-	if err != nil {
-		return nil, err
-	}
-	return &mcp.CallToolResultFor[any]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: formatToolResult(result)},
-		},
-	}, nil
+func DirbScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.DirbParams]) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	return callTool(ctx, ss, params, "dirb_scan", tools.DirbScan)
 }
 
 // NiktoScanHandler handles Nikto scan requests
-func NiktoScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.NiktoParams]) (*mcp.CallToolResultFor[any], error) {
-	result, err := tools.NiktoScan(params.Arguments)
-	if err != nil {
-		return nil, err
-	}
-	return &mcp.CallToolResultFor[any]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: formatToolResult(result)},
-		},
-	}, nil
+func NiktoScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.NiktoParams]) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	return callTool(ctx, ss, params, "nikto_scan", tools.NiktoScan)
 }
 
 // SqlmapScanHandler handles SQLmap scan requests
-func SqlmapScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.SqlmapParams]) (*mcp.CallToolResultFor[any], error) {
-	result, err := tools.SqlmapScan(params.Arguments)
-	if err != nil {
-		return nil, err
-	}
-	return &mcp.CallToolResultFor[any]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: formatToolResult(result)},
-		},
-	}, nil
+func SqlmapScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.SqlmapParams]) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	return callTool(ctx, ss, params, "sqlmap_scan", tools.SqlmapScan)
+}
+
+// SqlmapResumeHandler handles requests to resume a SQLmap session
+func SqlmapResumeHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.SqlmapResumeParams]) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	return callTool(ctx, ss, params, "sqlmap_resume", tools.SqlmapResume)
 }
 
 // HydraAttackHandler handles Hydra attack requests
-func HydraAttackHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.HydraParams]) (*mcp.CallToolResultFor[any], error) {
-	result, err := tools.HydraAttack(params.Arguments)
-	if err != nil {
-		return nil, err
-	}
-	return &mcp.CallToolResultFor[any]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: formatToolResult(result)},
-		},
-	}, nil
+func HydraAttackHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.HydraParams]) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	return callTool(ctx, ss, params, "hydra_attack", tools.HydraAttack)
 }
 
 // JohnCrackHandler handles John the Ripper requests
-func JohnCrackHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.JohnParams]) (*mcp.CallToolResultFor[any], error) {
-	result, err := tools.JohnCrack(params.Arguments)
-	if err != nil {
-		return nil, err
-	}
-	return &mcp.CallToolResultFor[any]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: formatToolResult(result)},
-		},
-	}, nil
+func JohnCrackHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.JohnParams]) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	return callTool(ctx, ss, params, "john_crack", tools.JohnCrack)
 }
 
 // WpscanAnalyzeHandler handles WPScan requests
-func WpscanAnalyzeHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.WpscanParams]) (*mcp.CallToolResultFor[any], error) {
-	result, err := tools.WpscanAnalyze(params.Arguments)
-	if err != nil {
-		return nil, err
-	}
-	return &mcp.CallToolResultFor[any]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: formatToolResult(result)},
-		},
-	}, nil
+func WpscanAnalyzeHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.WpscanParams]) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	return callTool(ctx, ss, params, "wpscan_analyze", tools.WpscanAnalyze)
 }
 
 // Enum4linuxScanHandler handles Enum4linux scan requests
-func Enum4linuxScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.Enum4linuxParams]) (*mcp.CallToolResultFor[any], error) {
-	result, err := tools.Enum4linuxScan(params.Arguments)
-	if err != nil {
-		return nil, err
-	}
-	return &mcp.CallToolResultFor[any]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: formatToolResult(result)},
-		},
-	}, nil
+func Enum4linuxScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.Enum4linuxParams]) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	return callTool(ctx, ss, params, "enum4linux_scan", tools.Enum4linuxScan)
 }
 
 // PingHandler handles ping requests
-func PingHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.PingParams]) (*mcp.CallToolResultFor[any], error) {
-	result, err := tools.Ping(params.Arguments)
+func PingHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.PingParams]) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	return callTool(ctx, ss, params, "ping", tools.Ping)
+}
+
+// NucleiScanHandler handles Nuclei scan requests
+func NucleiScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.NucleiParams]) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	return callTool(ctx, ss, params, "nuclei_scan", tools.NucleiScan)
+}
+
+// Sublist3rScanHandler handles Sublist3r subdomain enumeration requests
+func Sublist3rScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.Sublist3rParams]) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	return callTool(ctx, ss, params, "sublist3r_scan", tools.Sublist3rScan)
+}
+
+// ExecuteCommandHandler handles generic command execution requests
+func ExecuteCommandHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.GenericCommandParams]) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	return callTool(ctx, ss, params, "execute_command", tools.ExecuteGenericCommand)
+}
+
+// InitializeServer initializes the MCP server with tools
+func InitializeServer() *mcp.Server {
+	server := mcp.NewServer(&mcp.Implementation{Name: "kali-tools"}, nil)
+
+	mcp.AddTool(server, registeredTool("nmap_scan"), NmapScanHandler)
+
+	mcp.AddTool(server, registeredTool("gobuster_scan"), GobusterScanHandler)
+
+	mcp.AddTool(server, registeredTool("dirb_scan"), DirbScanHandler)
+
+	mcp.AddTool(server, registeredTool("nikto_scan"), NiktoScanHandler)
+
+	mcp.AddTool(server, registeredTool("sqlmap_scan"), SqlmapScanHandler)
+
+	mcp.AddTool(server, registeredTool("sqlmap_resume"), SqlmapResumeHandler)
+
+	mcp.AddTool(server, registeredTool("hydra_attack"), HydraAttackHandler)
+
+	mcp.AddTool(server, registeredTool("john_crack"), JohnCrackHandler)
+
+	mcp.AddTool(server, registeredTool("wpscan_analyze"), WpscanAnalyzeHandler)
+
+	mcp.AddTool(server, registeredTool("enum4linux_scan"), Enum4linuxScanHandler)
+
+	mcp.AddTool(server, registeredTool("ping"), PingHandler)
+
+	mcp.AddTool(server, registeredTool("nuclei_scan"), NucleiScanHandler)
+
+	mcp.AddTool(server, registeredTool("sublist3r_scan"), Sublist3rScanHandler)
+
+	mcp.AddTool(server, registeredTool("execute_command"), ExecuteCommandHandler)
+
+	registerJobTools(server)
+	registerCredentialTools(server)
+	registerRunResources(server)
+	registerPrompts(server)
+
+	return server
+}
+
+// registeredTool returns the MCP tool description of a tool from the tools registry
+func registeredTool(name string) *mcp.Tool {
+	def, ok := tools.Lookup(name)
+	if !ok {
+		panic(fmt.Sprintf("tool %s is not registered", name))
+	}
+	schema, err := def.Schema()
 	if err != nil {
-		return nil, err
+		panic(fmt.Sprintf("tool %s: %v", name, err))
 	}
-	return &mcp.CallToolResultFor[any]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: formatToolResult(result)},
-		},
-	}, nil
+	return describeTool(def, schema)
 }
 
-// NucleiScanHandler handles Nuclei scan requests
-func NucleiScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.NucleiParams]) (*mcp.CallToolResultFor[any], error) {
-	result, err := tools.NucleiScan(params.Arguments)
+// describeTool returns the MCP tool description of def with its
+// annotations and, if it has one, its risk level in _meta
+func describeTool(def tools.Definition, schema *jsonschema.Schema) *mcp.Tool {
+	tool := &mcp.Tool{
+		Name:        def.Name,
+		Title:       def.Title,
+		Description: def.Description,
+		InputSchema: schema,
+		Annotations: toolAnnotations(def),
+	}
+	if def.Risk != "" {
+		tool.Meta = mcp.Meta{riskMetaKey: def.Risk}
+	}
+	return tool
+}
+
+// withOutputSchema sets the output schema of tool to that of T, the type
+// of its structured content
+func withOutputSchema[T any](tool *mcp.Tool) *mcp.Tool {
+	schema, err := tools.OutputSchema[T]()
 	if err != nil {
-		return nil, err
+		panic(fmt.Sprintf("tool %s: %v", tool.Name, err))
 	}
-	return &mcp.CallToolResultFor[any]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: formatToolResult(result)},
-		},
-	}, nil
+	tool.OutputSchema = schema
+	return tool
 }
 
-// Sublist3rScanHandler handles Sublist3r subdomain enumeration requests
-func Sublist3rScanHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.Sublist3rParams]) (*mcp.CallToolResultFor[any], error) {
-	result, err := tools.Sublist3rScan(params.Arguments)
+// inputSchema returns the input schema of a tool taking parameters T
+func inputSchema[T any]() *jsonschema.Schema {
+	schema, err := tools.InputSchema[T]()
 	if err != nil {
-		return nil, err
+		panic(err)
 	}
-	return &mcp.CallToolResultFor[any]{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: formatToolResult(result)},
-		},
-	}, nil
+	return schema
 }
 
-// ExecuteCommandHandler handles generic command execution requests
-func ExecuteCommandHandler(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[tools.GenericCommandParams]) (*mcp.CallToolResultFor[any], error) {
-	result, err := tools.ExecuteGenericCommand(params.Arguments)
+// callTool runs the tool function fn with the arguments of an MCP tool
+// call, reporting its progress and output to the client while it runs and
+// recording the finished run. Risky tools only run once the caller
+// confirmed the call, if the server requires it.
+func callTool[T any](ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[T], tool string, fn func(context.Context, T) (*tools.ToolResult, error)) (*mcp.CallToolResultFor[tools.ToolResult], error) {
+	def, _ := tools.Lookup(tool)
+	if err := checkConfirmation(def, confirmedCall(params.Meta)); err != nil {
+		return toolCallResult(nil, confirmationHint(err))
+	}
+	ctx = withProgress(ctx, ss, params, tool)
+	ctx, flush := withLogging(ctx, ss, tool)
+	result, err := fn(ctx, params.Arguments)
+	flush()
+	if err == nil {
+		recordRun(tool, params.Arguments, result)
+	}
+	return toolCallResult(result, err)
+}
+
+// toolCallResult converts the outcome of a tool run into an MCP result. The
+// text content is meant to be read, the structured content carries the whole
+// result and matches the tool's output schema. Errors, including invalid
+// parameters, are reported as tool errors rather than protocol errors so
+// that the model can see and correct them. A non-zero exit code alone is
+// not an error, as many tools use it to report findings.
+func toolCallResult(result *tools.ToolResult, err error) (*mcp.CallToolResultFor[tools.ToolResult], error) {
 	if err != nil {
-		return nil, err
+		result = &tools.ToolResult{Error: err.Error()}
 	}
-	return &mcp.CallToolResultFor[any]{
+	return &mcp.CallToolResultFor[tools.ToolResult]{
 		Content: []mcp.Content{
 			&mcp.TextContent{Text: formatToolResult(result)},
 		},
+		StructuredContent: *result,
+		IsError:           executionFailed(result),
 	}, nil
 }
 
-// InitializeServer initializes the MCP server with tools
-func InitializeServer() *mcp.Server {
-	server := mcp.NewServer(&mcp.Implementation{Name: "kali-tools"}, nil)
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "nmap_scan",
-		Description: "Execute an Nmap scan against a target",
-	}, NmapScanHandler)
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "gobuster_scan",
-		Description: "Execute Gobuster to find directories, DNS subdomains, or virtual hosts",
-	}, GobusterScanHandler)
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "dirb_scan",
-		Description: "Execute Dirb web content scanner",
-	}, DirbScanHandler)
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "nikto_scan",
-		Description: "Execute Nikto web server scanner",
-	}, NiktoScanHandler)
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "sqlmap_scan",
-		Description: "Execute SQLmap SQL injection scanner",
-	}, SqlmapScanHandler)
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "hydra_attack",
-		Description: "Execute Hydra password cracking tool",
-	}, HydraAttackHandler)
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "john_crack",
-		Description: "Execute John the Ripper password cracker",
-	}, JohnCrackHandler)
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "wpscan_analyze",
-		Description: "Execute WPScan WordPress vulnerability scanner",
-	}, WpscanAnalyzeHandler)
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "enum4linux_scan",
-		Description: "Execute Enum4linux Windows/Samba enumeration tool",
-	}, Enum4linuxScanHandler)
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "ping",
-		Description: "Execute ping to test network connectivity",
-	}, PingHandler)
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "nuclei_scan",
-		Description: "Execute Nuclei template-based vulnerability scanner",
-	}, NucleiScanHandler)
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "sublist3r_scan",
-		Description: "Execute Sublist3r for subdomain enumeration",
-	}, Sublist3rScanHandler)
-
-	mcp.AddTool(server, &mcp.Tool{
-		Name:        "execute_command",
-		Description: "Execute an arbitrary command on the Kali server",
-	}, ExecuteCommandHandler)
-
-	return server
+// executionFailed reports whether a tool could not run to completion, as
+// opposed to exiting with a non-zero code, which tools like wpscan and
+// nikto use to report what they found. Runs that timed out still count as
+// successful if they produced partial results.
+func executionFailed(result *tools.ToolResult) bool {
+	switch {
+	case result.Error != "", result.Canceled, result.LimitExceeded != "":
+		return true
+	case result.TimedOut:
+		return !result.PartialResults
+	case result.Signal != "":
+		return true
+	default:
+		// The shell could not find or execute the command
+		return result.ReturnCode == 126 || result.ReturnCode == 127
+	}
 }
 
-// formatToolResult formats the tool result for display
+// formatToolResult formats the tool result for display, keeping both
+// streams whether or not the tool succeeded
 func formatToolResult(result *tools.ToolResult) string {
-	if result.Success {
-		return result.Stdout
+	var b strings.Builder
+	if result.Summary != "" {
+		b.WriteString(result.Summary + "\n\n")
+	}
+	if result.Error != "" {
+		fmt.Fprintf(&b, "Error: %s\n", result.Error)
+	}
+	b.WriteString(result.Stdout)
+	if result.Stderr != "" {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString("[stderr]\n" + result.Stderr)
+	}
+	switch {
+	case result.TimedOut:
+		b.WriteString("\n[timed out, output is partial]")
+	case result.Canceled:
+		b.WriteString("\n[canceled, output is partial]")
+	case !result.Success && result.Error == "":
+		fmt.Fprintf(&b, "\n[exit code %d]", result.ReturnCode)
 	}
-	return fmt.Sprintf("Error: %s\n%s", result.Error, result.Stderr)
+	return b.String()
 }
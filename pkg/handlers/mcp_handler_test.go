@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// TestToolParamsDocumented fails when a parameter of an MCP tool has no
+// description in the tool's input schema
+func TestToolParamsDocumented(t *testing.T) {
+	ctx := context.Background()
+	ct, st := mcp.NewInMemoryTransports()
+	ss, err := InitializeServer().Connect(ctx, st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close()
+	cs, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(ctx, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	list, err := cs.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Tools) == 0 {
+		t.Fatal("no tools are registered")
+	}
+	for _, tool := range list.Tools {
+		if tool.InputSchema == nil {
+			t.Errorf("%s: no input schema", tool.Name)
+			continue
+		}
+		for name, prop := range tool.InputSchema.Properties {
+			if prop.Description == "" {
+				t.Errorf("%s: parameter %s has no description", tool.Name, name)
+			}
+		}
+		for _, name := range tool.InputSchema.Required {
+			if tool.InputSchema.Properties[name] == nil {
+				t.Errorf("%s: required parameter %s is not defined", tool.Name, name)
+			}
+		}
+	}
+}
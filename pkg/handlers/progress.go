@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/tools"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// progressInterval is the minimum time between two progress notifications
+// of a tool call
+const progressInterval = time.Second
+
+// progressReporter turns the output of a tool call into MCP progress
+// notifications. Tools with a progress parser report what they print about
+// their progress, other tools the number of output lines.
+type progressReporter struct {
+	mu       sync.Mutex
+	ctx      context.Context
+	ss       *mcp.ServerSession
+	token    any
+	parse    parsers.ProgressParser
+	splitter *lineSplitter
+	lines    int
+	// base is the progress of the finished phases of a tool that reports
+	// its progress per phase, like Nmap
+	base  float64
+	phase parsers.Progress
+	sent  float64
+	last  time.Time
+}
+
+// withProgress returns a context that reports the progress of tool runs
+// to the client, if the client asked for it with a progress token
+func withProgress(ctx context.Context, ss *mcp.ServerSession, params mcp.RequestParams, tool string) context.Context {
+	token := params.GetProgressToken()
+	if ss == nil || token == nil {
+		return ctx
+	}
+	r := &progressReporter{
+		ctx:      ctx,
+		ss:       ss,
+		token:    token,
+		parse:    parsers.ProgressParserFor(tool),
+		splitter: newLineSplitter(true),
+	}
+	return executor.WithOutputHandler(tools.WithProgress(ctx), r.output)
+}
+
+// output splits a chunk of output into lines, taking carriage returns as
+// line ends too since progress lines are often redrawn with them
+func (r *progressReporter) output(chunk executor.Chunk) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.splitter.split(chunk, func(line []byte) {
+		if len(bytes.TrimSpace(line)) > 0 {
+			r.line(string(line))
+		}
+	})
+}
+
+// line reports the progress after a line of output
+func (r *progressReporter) line(line string) {
+	if r.parse == nil {
+		r.lines++
+		r.notify(parsers.Progress{Current: float64(r.lines), Message: fmt.Sprintf("%d lines of output", r.lines)})
+		return
+	}
+	p, ok := r.parse(line)
+	if !ok {
+		return
+	}
+	// A drop in progress starts a new phase
+	if p.Current < r.phase.Current {
+		if r.phase.Total > 0 {
+			r.base += r.phase.Total
+		} else {
+			r.base += r.phase.Current
+		}
+	}
+	r.phase = p
+	p.Current += r.base
+	if p.Total > 0 {
+		p.Total += r.base
+	}
+	r.notify(p)
+}
+
+// notify sends p unless it does not advance the progress or, before the
+// work is complete, the previous notification was sent less than
+// progressInterval ago
+func (r *progressReporter) notify(p parsers.Progress) {
+	complete := p.Total > 0 && p.Current >= p.Total
+	if p.Current <= r.sent || (!complete && time.Since(r.last) < progressInterval) {
+		return
+	}
+	r.sent = p.Current
+	r.last = time.Now()
+	r.ss.NotifyProgress(r.ctx, &mcp.ProgressNotificationParams{
+		ProgressToken: r.token,
+		Progress:      p.Current,
+		Total:         p.Total,
+		Message:       p.Message,
+	})
+}
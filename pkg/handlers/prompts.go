@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/prompts"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// registerPrompts exposes the pentest playbooks as prompts. A prompt
+// directory that fails to load is logged and leaves the server without
+// prompts rather than without tools.
+func registerPrompts(server *mcp.Server) {
+	list, err := prompts.All()
+	if err != nil {
+		log.Printf("Failed to load prompts: %v", err)
+		return
+	}
+	for _, p := range list {
+		prompt := &mcp.Prompt{
+			Name:        p.Name,
+			Title:       p.Title,
+			Description: p.Description,
+		}
+		for _, arg := range p.Arguments {
+			prompt.Arguments = append(prompt.Arguments, &mcp.PromptArgument{
+				Name:        arg.Name,
+				Description: arg.Description,
+				Required:    arg.Required,
+			})
+		}
+		server.AddPrompt(prompt, promptHandler(p))
+	}
+}
+
+// promptHandler returns the handler expanding a playbook into a user message
+func promptHandler(p *prompts.Prompt) mcp.PromptHandler {
+	return func(ctx context.Context, ss *mcp.ServerSession, params *mcp.GetPromptParams) (*mcp.GetPromptResult, error) {
+		text, err := p.Render(params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.GetPromptResult{
+			Description: p.Description,
+			Messages: []*mcp.PromptMessage{
+				{Role: "user", Content: &mcp.TextContent{Text: text}},
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/runs"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/tools"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// URI prefixes of the run resources
+const (
+	runURIPrefix    = "kali://runs/"
+	targetURIPrefix = "kali://targets/"
+)
+
+// maxResourceSize is the largest artifact returned as a resource; larger
+// files have to be read on the server
+const maxResourceSize = 32 << 20
+
+// streamArtifacts maps the short resource names of the output streams to
+// the files holding them
+var streamArtifacts = map[string]string{
+	"stdout": runs.StdoutFile,
+	"stderr": runs.StderrFile,
+}
+
+// registerRunResources exposes the persisted tool runs as resources. Runs
+// recorded later are added as they finish, which notifies clients that
+// the resource list changed.
+func registerRunResources(server *mcp.Server) {
+	if err := runs.DefaultStore.Load(); err != nil {
+		log.Printf("Failed to load runs: %v", err)
+	}
+	for _, run := range runs.DefaultStore.List("") {
+		addRunResources(server, run)
+	}
+	runs.DefaultStore.OnRecord(func(run *runs.Run) {
+		addRunResources(server, run)
+	})
+	runs.DefaultStore.OnRemove(func(run *runs.Run) {
+		server.RemoveResources(runResourceURIs(run)...)
+	})
+
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "run",
+		URITemplate: runURIPrefix + "{id}",
+		Description: "Tool, arguments, artifacts and result of a tool run, whose output is in its stdout and stderr artifacts",
+		MIMEType:    "application/json",
+	}, readRunResource)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "run_artifact",
+		URITemplate: runURIPrefix + "{id}/{+name}",
+		Description: "Output of a tool run: stdout, stderr or a file the tool wrote such as nmap.xml",
+	}, readRunResource)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "target_runs",
+		URITemplate: targetURIPrefix + "{target}/runs",
+		Description: "Runs against a target, most recent first. The target is the target, url or domain argument of the tool, URL encoded",
+		MIMEType:    "application/json",
+	}, readTargetRuns)
+}
+
+// recordRun records a finished run of tool with params, whether it was
+// started by an MCP tool call or an HTTP request
+func recordRun(tool string, params any, result *tools.ToolResult) {
+	arguments, _ := json.Marshal(params)
+	if _, err := runs.DefaultStore.Record(tool, arguments, result); err != nil {
+		log.Printf("Failed to record %s run: %v", tool, err)
+	}
+}
+
+// addRunResources adds the metadata and artifacts of run as resources
+func addRunResources(server *mcp.Server, run *runs.Run) {
+	title := run.Tool
+	if run.Target != "" {
+		title += " " + run.Target
+	}
+	title += " at " + run.FinishedAt.Format("2006-01-02 15:04:05")
+
+	server.AddResource(&mcp.Resource{
+		Name:        run.ID,
+		Title:       title,
+		URI:         runURIPrefix + run.ID,
+		Description: run.Summary,
+		MIMEType:    "application/json",
+	}, readRunResource)
+	for _, artifact := range run.Artifacts {
+		name := artifactResourceName(artifact)
+		server.AddResource(&mcp.Resource{
+			Name:     run.ID + "/" + name,
+			Title:    title + ": " + name,
+			URI:      runURIPrefix + run.ID + "/" + name,
+			MIMEType: artifactMIMEType(artifact),
+		}, readRunResource)
+	}
+}
+
+// runResourceURIs returns the URIs of the resources of run
+func runResourceURIs(run *runs.Run) []string {
+	uris := []string{runURIPrefix + run.ID}
+	for _, artifact := range run.Artifacts {
+		uris = append(uris, runURIPrefix+run.ID+"/"+artifactResourceName(artifact))
+	}
+	return uris
+}
+
+// artifactResourceName returns the name of an artifact in its resource URI,
+// which is the short name of the output streams
+func artifactResourceName(artifact string) string {
+	for stream, file := range streamArtifacts {
+		if artifact == file {
+			return stream
+		}
+	}
+	return artifact
+}
+
+// readRunResource reads the metadata or an artifact of a run
+func readRunResource(ctx context.Context, ss *mcp.ServerSession, params *mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+	id, name, _ := strings.Cut(strings.TrimPrefix(params.URI, runURIPrefix), "/")
+	if _, err := runs.DefaultStore.Get(id); err != nil {
+		return nil, mcp.ResourceNotFoundError(params.URI)
+	}
+
+	var file string
+	if name == "" {
+		file = filepath.Join(executor.ArtifactDir, id, runs.MetadataFile)
+	} else {
+		if stream, ok := streamArtifacts[name]; ok {
+			name = stream
+		}
+		var err error
+		if file, err = runs.DefaultStore.Path(id, name); err != nil {
+			return nil, mcp.ResourceNotFoundError(params.URI)
+		}
+	}
+
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return nil, mcp.ResourceNotFoundError(params.URI)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxResourceSize {
+		return nil, fmt.Errorf("%s is %d bytes, more than the %d bytes a resource may hold; read %s on the server instead", params.URI, info.Size(), maxResourceSize, file)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := &mcp.ResourceContents{URI: params.URI, MIMEType: artifactMIMEType(file)}
+	if isText(contents.MIMEType) && utf8.Valid(data) {
+		contents.Text = string(data)
+	} else {
+		contents.Blob = data
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{contents}}, nil
+}
+
+// readTargetRuns lists the runs against a target, without their results
+func readTargetRuns(ctx context.Context, ss *mcp.ServerSession, params *mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+	target, err := url.PathUnescape(strings.TrimSuffix(strings.TrimPrefix(params.URI, targetURIPrefix), "/runs"))
+	if err != nil || target == "" {
+		return nil, mcp.ResourceNotFoundError(params.URI)
+	}
+
+	list := []runs.Run{}
+	for _, run := range runs.DefaultStore.List(target) {
+		r := *run
+		r.Result = nil
+		list = append(list, r)
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+		{URI: params.URI, MIMEType: "application/json", Text: string(data)},
+	}}, nil
+}
+
+// artifactMIMEType returns the MIME type of an artifact from its extension
+func artifactMIMEType(name string) string {
+	switch ext := path.Ext(name); ext {
+	case ".log", ".txt":
+		return "text/plain"
+	case ".jsonl":
+		return "application/x-ndjson"
+	default:
+		if t := mime.TypeByExtension(ext); t != "" {
+			return t
+		}
+		return "application/octet-stream"
+	}
+}
+
+// isText reports whether a MIME type is read as text rather than a blob
+func isText(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/") || strings.Contains(mimeType, "json") || strings.Contains(mimeType, "xml")
+}
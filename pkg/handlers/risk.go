@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/tools"
+	"github.com/gin-gonic/gin"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Keys of the server's entries in the _meta of tools and tool calls. Tools
+// carry their risk level, and callers confirm runs of risky tools with
+// confirmedMetaKey set to true.
+const (
+	riskMetaKey      = "kali/risk"
+	confirmedMetaKey = "kali/confirmed"
+)
+
+// confirmHeader is the header HTTP callers confirm runs of risky tools with
+const confirmHeader = "X-Kali-Confirm"
+
+// toolAnnotations returns the MCP annotations of a tool from its definition
+func toolAnnotations(def tools.Definition) *mcp.ToolAnnotations {
+	destructive, openWorld := def.Destructive, def.OpenWorld
+	return &mcp.ToolAnnotations{
+		Title:           def.Title,
+		ReadOnlyHint:    def.ReadOnly,
+		DestructiveHint: &destructive,
+		IdempotentHint:  def.Idempotent,
+		OpenWorldHint:   &openWorld,
+	}
+}
+
+// checkConfirmation returns an error if the tool needs confirmation under
+// the server's policy and the call was not confirmed
+func checkConfirmation(def tools.Definition, confirmed bool) error {
+	if confirmed || !def.NeedsConfirmation() {
+		return nil
+	}
+	return &tools.ConfirmationError{Tool: def.Name, Risk: def.Risk}
+}
+
+// confirmedCall reports whether an MCP tool call was confirmed in its _meta
+func confirmedCall(meta mcp.Meta) bool {
+	confirmed, _ := meta[confirmedMetaKey].(bool)
+	return confirmed
+}
+
+// confirmationHint tells MCP callers how to confirm a call
+func confirmationHint(err error) error {
+	return fmt.Errorf("%w: ask the user to confirm, then call it again with %q set to true in _meta", err, confirmedMetaKey)
+}
+
+// ConfirmTool returns middleware that rejects requests running the named
+// tool with 428 Precondition Required, unless they are confirmed with the
+// X-Kali-Confirm header or the tool does not need confirmation. Tools
+// missing from the registry are classified critical.
+func ConfirmTool(tool string) gin.HandlerFunc {
+	def, ok := tools.Lookup(tool)
+	if !ok {
+		def = tools.Definition{Name: tool, Risk: tools.RiskCritical}
+	}
+	return func(c *gin.Context) {
+		if !confirmRequest(c, def) {
+			c.Abort()
+		}
+	}
+}
+
+// confirmRequest checks that an HTTP request running def is confirmed if
+// it has to be, and responds with an error if it is not
+func confirmRequest(c *gin.Context, def tools.Definition) bool {
+	err := checkConfirmation(def, c.GetHeader(confirmHeader) == "true")
+	if err == nil {
+		return true
+	}
+	c.JSON(http.StatusPreconditionRequired, gin.H{
+		"error": fmt.Sprintf("%v: ask the user to confirm, then send the request again with the %s: true header", err, confirmHeader),
+		"risk":  def.Risk,
+	})
+	return false
+}
+
+// ListToolsHandler lists the registered tools with their risk level and
+// hints, and whether the server requires confirmation to run them
+func ListToolsHandler(c *gin.Context) {
+	list := []gin.H{}
+	for _, def := range tools.Definitions() {
+		list = append(list, gin.H{
+			"name":                  def.Name,
+			"title":                 def.Title,
+			"description":           def.Description,
+			"risk":                  def.Risk,
+			"read_only":             def.ReadOnly,
+			"destructive":           def.Destructive,
+			"idempotent":            def.Idempotent,
+			"open_world":            def.OpenWorld,
+			"requires_confirmation": def.NeedsConfirmation(),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"tools": list})
+}
@@ -1,125 +1,137 @@
 package handlers
 
 import (
-	"bufio"
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"os/exec"
+	"sync"
 	"time"
 
+	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/tools"
 	"github.com/gin-gonic/gin"
 )
 
 // StreamEvent represents a server-sent event
 type StreamEvent struct {
-	Type      string    `json:"type"`      // "stdout", "stderr", "exit"
-	Data      string    `json:"data"`      // The actual output line
-	Timestamp time.Time `json:"timestamp"` // When the event occurred
+	Type      string    `json:"type"`                // "queued", "stdout", "stderr", "exit", "error"
+	Seq       uint64    `json:"seq,omitempty"`       // Order of the output chunk across stdout and stderr
+	Data      string    `json:"data"`                // The output chunk, exactly as produced by the command
+	Encoding  string    `json:"encoding,omitempty"`  // "base64" if the data is not valid UTF-8
+	Timestamp time.Time `json:"timestamp"`           // When the event occurred
 	ExitCode  int       `json:"exit_code,omitempty"` // Only for "exit" type
+	Signal    string    `json:"signal,omitempty"`    // Only for "exit" type
+	TimedOut  bool      `json:"timed_out,omitempty"` // Only for "exit" type
 }
 
-// StreamCommandHandler executes a command and streams the output
-func StreamCommandHandler(c *gin.Context) {
-	var data map[string]string
-	if err := c.BindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request."})
-		return
+// sseWriter serializes events of one streaming response
+type sseWriter struct {
+	mu      sync.Mutex
+	c       *gin.Context
+	started bool
+	pending map[string][]byte
+}
+
+// send writes event, setting the streaming headers before the first one
+func (w *sseWriter) send(event StreamEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", "text/event-stream")
+		w.c.Header("Cache-Control", "no-cache")
+		w.c.Header("Connection", "keep-alive")
+		w.c.Header("Transfer-Encoding", "chunked")
 	}
+	sendSSEEvent(w.c, event)
+}
 
-	command, ok := data["command"]
-	if !ok || command == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Command parameter is required"})
+// output sends a chunk of command output. Bytes of a UTF-8 character cut
+// off at the end of the chunk are held back until the next chunk.
+func (w *sseWriter) output(chunk executor.Chunk) {
+	data := append(w.pending[chunk.Stream], chunk.Data...)
+	data, rest := executor.SplitIncompleteRune(data)
+	w.pending[chunk.Stream] = append([]byte(nil), rest...)
+	if len(data) == 0 {
 		return
 	}
+	text, encoding := executor.EncodeOutput(data)
+	w.send(StreamEvent{
+		Type:      chunk.Stream,
+		Seq:       chunk.Seq,
+		Data:      text,
+		Encoding:  encoding,
+		Timestamp: chunk.Time,
+	})
+}
 
-	// Set headers for streaming
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("Transfer-Encoding", "chunked")
-
-	// Create command with context for cancellation
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Minute)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "sh", "-c", command)
-
-	// Get stdout and stderr pipes
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		sendSSEError(c, fmt.Sprintf("Failed to create stdout pipe: %v", err))
-		return
+// flush sends output still held back, which can only be invalid UTF-8
+func (w *sseWriter) flush() {
+	for _, stream := range []string{executor.StreamStdout, executor.StreamStderr} {
+		if data := w.pending[stream]; len(data) > 0 {
+			text, encoding := executor.EncodeOutput(data)
+			w.send(StreamEvent{Type: stream, Data: text, Encoding: encoding, Timestamp: time.Now()})
+		}
 	}
+}
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		sendSSEError(c, fmt.Sprintf("Failed to create stderr pipe: %v", err))
+// StreamCommandHandler executes a command and streams the output
+func StreamCommandHandler(c *gin.Context) {
+	var data map[string]interface{}
+	if err := c.BindJSON(&data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request."})
 		return
 	}
 
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		sendSSEError(c, fmt.Sprintf("Failed to start command: %v", err))
+	command := getStringParam(data, "command", "")
+	if command == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Command parameter is required"})
 		return
 	}
 
-	// Channel to signal when readers are done
-	done := make(chan bool, 2)
+	w := &sseWriter{c: c, pending: make(map[string][]byte)}
 
-	// Read stdout
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			event := StreamEvent{
-				Type:      "stdout",
-				Data:      scanner.Text(),
+	ctx := executor.WithOutputHandler(c.Request.Context(), w.output)
+	ctx = executor.WithQueueHandler(ctx, func(position int) {
+		if position > 0 {
+			w.send(StreamEvent{
+				Type:      "queued",
+				Data:      fmt.Sprintf("Waiting at queue position %d", position),
 				Timestamp: time.Now(),
-			}
-			sendSSEEvent(c, event)
+			})
 		}
-		done <- true
-	}()
-
-	// Read stderr
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			event := StreamEvent{
-				Type:      "stderr",
-				Data:      scanner.Text(),
-				Timestamp: time.Now(),
-			}
-			sendSSEEvent(c, event)
-		}
-		done <- true
-	}()
-
-	// Wait for both readers to finish
-	<-done
-	<-done
+	})
 
-	// Wait for command to complete
-	err = cmd.Wait()
-	exitCode := 0
+	// Streamed commands run and are recorded as execute_command
+	params := tools.GenericCommandParams{
+		Command:        command,
+		TimeoutSeconds: getIntParam(data, "timeout_seconds", 0),
+	}
+	result, err := tools.ExecuteGenericCommand(ctx, params)
 	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			exitCode = exitError.ExitCode()
+		w.mu.Lock()
+		started := w.started
+		w.mu.Unlock()
+		if !started && errors.Is(err, executor.ErrQueueFull) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
 		}
+		w.send(StreamEvent{Type: "error", Data: err.Error(), Timestamp: time.Now()})
+		return
 	}
+	w.flush()
+	recordRun("execute_command", params, result)
 
 	// Send exit event
-	event := StreamEvent{
+	w.send(StreamEvent{
 		Type:      "exit",
 		Data:      "Command completed",
 		Timestamp: time.Now(),
-		ExitCode:  exitCode,
-	}
-	sendSSEEvent(c, event)
-
-	// Flush any remaining data
-	c.Writer.Flush()
+		ExitCode:  result.ReturnCode,
+		Signal:    result.Signal,
+		TimedOut:  result.TimedOut,
+	})
 }
 
 // sendSSEEvent sends a server-sent event
@@ -128,13 +140,3 @@ func sendSSEEvent(c *gin.Context, event StreamEvent) {
 	fmt.Fprintf(c.Writer, "data: %s\n\n", string(data))
 	c.Writer.Flush()
 }
-
-// sendSSEError sends an error as a server-sent event
-func sendSSEError(c *gin.Context, errMsg string) {
-	event := StreamEvent{
-		Type:      "error",
-		Data:      errMsg,
-		Timestamp: time.Now(),
-	}
-	sendSSEEvent(c, event)
-}
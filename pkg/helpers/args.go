@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitArgs splits a command-line fragment into an argument vector using
+// POSIX shell quoting rules, without performing any expansion.
+// Single quotes preserve everything literally, inside double quotes a
+// backslash only escapes '"', '\', '$' and '`', and an unquoted backslash
+// escapes the following character. Shell metacharacters such as ';', '|' or '$('
+// have no special meaning and end up in the resulting arguments verbatim.
+func SplitArgs(input string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range input {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\"\\$`", r) {
+				current.WriteRune('\\')
+			}
+			current.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("error: trailing backslash in arguments %q", input)
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("error: unterminated %c quote in arguments %q", quote, input)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
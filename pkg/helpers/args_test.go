@@ -0,0 +1,47 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSplitArgs splits argument strings with POSIX shell quoting rules
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{input: "", want: nil},
+		{input: "  \t\n ", want: nil},
+		{input: "-sV -p 80,443", want: []string{"-sV", "-p", "80,443"}},
+		{input: "  -sV\t-T4\n", want: []string{"-sV", "-T4"}},
+		{input: `-H 'User-Agent: x y'`, want: []string{"-H", "User-Agent: x y"}},
+		{input: `-H "User-Agent: x y"`, want: []string{"-H", "User-Agent: x y"}},
+		{input: `--data=a'b c'"d e"`, want: []string{"--data=ab cd e"}},
+		{input: `'' ""`, want: []string{"", ""}},
+		{input: `'a\b' '$x' '"'`, want: []string{`a\b`, "$x", `"`}},
+		{input: `"a\"b" "c\\d" "\$x" "\` + "`" + `" "\n"`, want: []string{`a"b`, `c\d`, "$x", "`", `\n`}},
+		{input: `"it's"`, want: []string{"it's"}},
+		{input: `a\ b \'c\" \\`, want: []string{"a b", `'c"`, `\`}},
+		{input: `\ `, want: []string{" "}},
+		{input: "; rm -rf / | cat $(id) `id` && x > y", want: []string{";", "rm", "-rf", "/", "|", "cat", "$(id)", "`id`", "&&", "x", ">", "y"}},
+		{input: "'é ü' \"ß\"", want: []string{"é ü", "ß"}},
+		{input: `-p 'unterminated`, wantErr: true},
+		{input: `-p "unterminated`, wantErr: true},
+		{input: `"a\"`, wantErr: true},
+		{input: `trailing\`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := SplitArgs(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SplitArgs(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitArgs(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/runs"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/tools"
+)
+
+const (
+	// DefaultMaxFinishedJobs is the number of finished jobs kept for retrieval
+	DefaultMaxFinishedJobs = 100
+
+	// maxLiveOutput is the amount of combined output kept in memory per job
+	maxLiveOutput = 1 << 20
+)
+
+// Status represents the state of a job
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+// Job is a snapshot of a background tool execution
+type Job struct {
+	ID            string            `json:"id"`
+	Tool          string            `json:"tool"`
+	Arguments     json.RawMessage   `json:"arguments,omitempty"`
+	Status        Status            `json:"status"`
+	Priority      int               `json:"priority,omitempty"`
+	QueuePosition int               `json:"queue_position,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	StartedAt     *time.Time        `json:"started_at,omitempty"`
+	FinishedAt    *time.Time        `json:"finished_at,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	Result        *tools.ToolResult `json:"result,omitempty"`
+}
+
+// Output is the combined stdout and stderr of a job in the order it was
+// produced, base64 encoded if it is not valid UTF-8
+type Output struct {
+	JobID     string `json:"job_id"`
+	Status    Status `json:"status"`
+	Output    string `json:"output"`
+	Encoding  string `json:"encoding,omitempty"`
+	Truncated bool   `json:"truncated"`
+}
+
+// job is the internal, mutable state of a job
+type job struct {
+	mu        sync.Mutex
+	info      Job
+	live      strings.Builder
+	truncated bool
+	cancel    context.CancelFunc
+}
+
+// Manager runs tools in the background and keeps track of their results
+type Manager struct {
+	mu              sync.Mutex
+	jobs            map[string]*job
+	MaxFinishedJobs int
+}
+
+// DefaultManager is the job manager shared by the Gin and MCP handlers
+var DefaultManager = NewManager()
+
+func init() {
+	// Let tools that build on an earlier job, such as sqlmap_resume, find its run
+	tools.JobRunID = DefaultManager.RunID
+}
+
+// NewManager creates a new job Manager
+func NewManager() *Manager {
+	return &Manager{
+		jobs:            make(map[string]*job),
+		MaxFinishedJobs: DefaultMaxFinishedJobs,
+	}
+}
+
+// Start runs the named tool in the background and returns the new job. Jobs
+// with a higher priority are started first when the executor queue is busy.
+func (m *Manager) Start(tool string, arguments json.RawMessage, priority int) (*Job, error) {
+	def, ok := tools.Lookup(tool)
+	if !ok {
+		return nil, fmt.Errorf("unknown tool: %s", tool)
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	// StartedAt is set by setQueuePosition once the limiter grants the job
+	// a slot
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		info: Job{
+			ID:        id,
+			Tool:      tool,
+			Arguments: arguments,
+			Status:    StatusRunning,
+			CreatedAt: time.Now(),
+		},
+		cancel: cancel,
+	}
+
+	m.mu.Lock()
+	m.jobs[id] = j
+	m.mu.Unlock()
+
+	ctx = executor.WithOutputHandler(ctx, j.appendOutput)
+	ctx = executor.WithQueueHandler(ctx, j.setQueuePosition)
+	ctx = executor.WithPriority(ctx, priority)
+
+	go func() {
+		defer cancel()
+		log.Printf("Job %s started: %s", id, tool)
+		result, err := def.Run(ctx, arguments)
+		if err == nil {
+			if _, err := runs.DefaultStore.Record(tool, arguments, result); err != nil {
+				log.Printf("Failed to record the run of job %s: %v", id, err)
+			}
+		}
+		j.finish(result, err, ctx.Err() != nil)
+		log.Printf("Job %s finished: %s", id, j.snapshot().Status)
+		m.prune()
+	}()
+
+	return j.snapshot(), nil
+}
+
+// Get returns the job with the given ID
+func (m *Manager) Get(id string) (*Job, error) {
+	j, err := m.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	return j.snapshot(), nil
+}
+
+// List returns all known jobs, newest first
+func (m *Manager) List() []*Job {
+	m.mu.Lock()
+	list := make([]*Job, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		list = append(list, j.snapshot())
+	}
+	m.mu.Unlock()
+
+	sort.Slice(list, func(i, k int) bool { return list[i].CreatedAt.After(list[k].CreatedAt) })
+	return list
+}
+
+// Output returns the output of the job, limited to the last tailLines lines if tailLines > 0
+func (m *Manager) Output(id string, tailLines int) (*Output, error) {
+	j, err := m.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	j.mu.Lock()
+	output := &Output{JobID: id, Status: j.info.Status, Truncated: j.truncated}
+	text := j.live.String()
+	j.mu.Unlock()
+
+	if tailLines > 0 {
+		lines := strings.SplitAfter(text, "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		if len(lines) > tailLines {
+			text = strings.Join(lines[len(lines)-tailLines:], "")
+			output.Truncated = true
+		}
+	}
+	output.Output, output.Encoding = executor.EncodeOutput([]byte(text))
+	return output, nil
+}
+
+// Cancel stops a running job
+func (m *Manager) Cancel(id string) (*Job, error) {
+	j, err := m.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	j.mu.Lock()
+	running := j.info.Status == StatusRunning || j.info.Status == StatusQueued
+	j.mu.Unlock()
+	if !running {
+		return nil, fmt.Errorf("job %s is not running", id)
+	}
+
+	j.cancel()
+	return j.snapshot(), nil
+}
+
+// RunID returns the run ID of a finished job, which identifies the
+// artifacts the run left behind
+func (m *Manager) RunID(id string) (string, error) {
+	job, err := m.Get(id)
+	if err != nil {
+		return "", err
+	}
+	if job.FinishedAt == nil {
+		return "", fmt.Errorf("job %s has not finished yet", id)
+	}
+	if job.Result == nil || job.Result.RunID == "" {
+		return "", fmt.Errorf("job %s has no run", id)
+	}
+	return job.Result.RunID, nil
+}
+
+// lookup returns the internal job with the given ID
+func (m *Manager) lookup(id string) (*job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("job not found: %s", id)
+	}
+	return j, nil
+}
+
+// prune removes the oldest finished jobs beyond MaxFinishedJobs
+func (m *Manager) prune() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var finished []*job
+	for _, j := range m.jobs {
+		j.mu.Lock()
+		if j.info.FinishedAt != nil {
+			finished = append(finished, j)
+		}
+		j.mu.Unlock()
+	}
+	if len(finished) <= m.MaxFinishedJobs {
+		return
+	}
+
+	sort.Slice(finished, func(i, k int) bool { return finished[i].info.FinishedAt.Before(*finished[k].info.FinishedAt) })
+	for _, j := range finished[:len(finished)-m.MaxFinishedJobs] {
+		delete(m.jobs, j.info.ID)
+	}
+}
+
+// setQueuePosition tracks whether the job is waiting for a free executor slot
+func (j *job) setQueuePosition(position int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.info.FinishedAt != nil {
+		return
+	}
+	j.info.QueuePosition = position
+	if position > 0 {
+		j.info.Status = StatusQueued
+		return
+	}
+	j.info.Status = StatusRunning
+	if j.info.StartedAt == nil {
+		now := time.Now()
+		j.info.StartedAt = &now
+	}
+}
+
+// appendOutput records output, keeping only the most recent maxLiveOutput bytes
+func (j *job) appendOutput(chunk executor.Chunk) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.live.Write(chunk.Data)
+	if j.live.Len() > maxLiveOutput {
+		tail := j.live.String()[j.live.Len()-maxLiveOutput/2:]
+		// Do not start in the middle of a UTF-8 character
+		for i := 0; i < utf8.UTFMax && i < len(tail); i++ {
+			if utf8.RuneStart(tail[i]) {
+				tail = tail[i:]
+				break
+			}
+		}
+		j.live.Reset()
+		j.live.WriteString(tail)
+		j.truncated = true
+	}
+}
+
+// finish records the result of the job
+func (j *job) finish(result *tools.ToolResult, err error, canceled bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	j.info.FinishedAt = &now
+	j.info.QueuePosition = 0
+	j.info.Result = result
+	switch {
+	case canceled:
+		j.info.Status = StatusCanceled
+	case err != nil:
+		j.info.Status = StatusFailed
+		j.info.Error = err.Error()
+	case !result.Success:
+		j.info.Status = StatusFailed
+	default:
+		j.info.Status = StatusCompleted
+	}
+}
+
+// snapshot returns a copy of the job's public state
+func (j *job) snapshot() *Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	info := j.info
+	return &info
+}
+
+// newJobID generates a random job identifier
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,22 @@
+package jobs
+
+// StartParams represents parameters for starting a job
+type StartParams struct {
+	Tool      string                 `json:"tool" jsonschema:"Name of the tool to run" example:"nmap_scan"`
+	Arguments map[string]interface{} `json:"arguments,omitempty" jsonschema:"Arguments of the tool, as the tool itself takes them" example:"{\"target\":\"192.168.1.10\"}"`
+	Priority  int                    `json:"priority,omitempty" jsonschema:"Queue priority, jobs with a higher priority start first" example:"10"`
+}
+
+// JobParams represents parameters identifying a job
+type JobParams struct {
+	JobID string `json:"job_id" jsonschema:"ID of the job as returned by job_start"`
+}
+
+// OutputParams represents parameters for retrieving job output
+type OutputParams struct {
+	JobID     string `json:"job_id" jsonschema:"ID of the job as returned by job_start"`
+	TailLines int    `json:"tail_lines,omitempty" jsonschema:"Return only the last lines of the output, all of it when zero" example:"100"`
+}
+
+// ListParams represents parameters for listing jobs
+type ListParams struct{}
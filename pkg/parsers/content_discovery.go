@@ -0,0 +1,140 @@
+package parsers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WebPath is a URL found by a content discovery tool
+type WebPath struct {
+	URL      string `json:"url"`
+	Status   int    `json:"status,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+	Redirect string `json:"redirect,omitempty"`
+	// New is set when the URL was not found by any earlier run
+	New bool `json:"new"`
+}
+
+// Subdomain is a host name found by DNS or virtual host enumeration
+type Subdomain struct {
+	Subdomain string   `json:"subdomain"`
+	IPs       []string `json:"ips,omitempty"`
+	CNAME     string   `json:"cname,omitempty"`
+	Ports     []int    `json:"ports,omitempty"`
+	Status    int      `json:"status,omitempty"`
+	Size      int64    `json:"size,omitempty"`
+	// Wildcard is set when the name only resolves through a wildcard record
+	Wildcard bool `json:"wildcard,omitempty"`
+	// New is set when the subdomain was not found by any earlier run
+	New bool `json:"new"`
+}
+
+// ContentDiscovery is the parsed output of gobuster or dirb
+type ContentDiscovery struct {
+	Paths      []WebPath   `json:"paths,omitempty"`
+	Subdomains []Subdomain `json:"subdomains,omitempty"`
+}
+
+var (
+	// "/admin (Status: 301) [Size: 178] [--> http://t/admin/]"
+	gobusterDirPattern = regexp.MustCompile(`^(\S+)\s+\(Status:\s*(\d+)\)(?:\s+\[Size:\s*(\d+)\])?(?:\s+\[-->\s*(\S+)\])?`)
+	// "Found: dev.example.com Status: 200 [Size: 1234]"
+	gobusterVhostPattern = regexp.MustCompile(`^Found:\s+(\S+)\s+(?:\(?Status:\s*(\d+)\)?)(?:\s+\[Size:\s*(\d+)\])?`)
+	// "Found: www.example.com [1.2.3.4, ::1]"
+	gobusterDNSPattern = regexp.MustCompile(`^Found:\s+(\S+?)\.?(?:\s+\[([^\]]*)\])?$`)
+	// "[Status=200] [Length=1234] [Word=admin] http://t/admin"
+	gobusterFuzzPattern = regexp.MustCompile(`\[Status=(\d+)\]\s+\[Length=(\d+)\](?:\s+\[Word=[^\]]*\])?\s+(\S+)`)
+	// "+ http://t/index.html (CODE:200|SIZE:1234)"
+	dirbFilePattern = regexp.MustCompile(`^\+\s+(\S+)\s+\(CODE:(\d+)\|SIZE:(\d+)\)`)
+	// "==> DIRECTORY: http://t/admin/"
+	dirbDirectoryPattern = regexp.MustCompile(`^==> DIRECTORY:\s+(\S+)`)
+)
+
+// ParseGobusterOutput parses gobuster output of the given mode (dir, vhost,
+// dns or fuzz). baseURL turns the paths printed in dir mode into URLs.
+func ParseGobusterOutput(mode, baseURL, output string) *ContentDiscovery {
+	d := &ContentDiscovery{}
+	seen := map[string]bool{}
+	for _, line := range cleanLines(output) {
+		switch mode {
+		case "dir":
+			m := gobusterDirPattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			url := m[1]
+			if !strings.Contains(url, "://") {
+				url = strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(url, "/")
+			}
+			d.addPath(seen, WebPath{URL: url, Status: atoi(m[2]), Size: atoi64(m[3]), Redirect: m[4]})
+		case "fuzz":
+			m := gobusterFuzzPattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			d.addPath(seen, WebPath{URL: m[3], Status: atoi(m[1]), Size: atoi64(m[2])})
+		case "vhost":
+			m := gobusterVhostPattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			d.addSubdomain(seen, Subdomain{Subdomain: m[1], Status: atoi(m[2]), Size: atoi64(m[3])})
+		case "dns":
+			m := gobusterDNSPattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			d.addSubdomain(seen, Subdomain{Subdomain: m[1], IPs: splitList(m[2])})
+		}
+	}
+	return d
+}
+
+// ParseDirbOutput parses dirb output
+func ParseDirbOutput(output string) *ContentDiscovery {
+	d := &ContentDiscovery{}
+	seen := map[string]bool{}
+	for _, line := range cleanLines(output) {
+		if m := dirbFilePattern.FindStringSubmatch(line); m != nil {
+			d.addPath(seen, WebPath{URL: m[1], Status: atoi(m[2]), Size: atoi64(m[3])})
+		} else if m := dirbDirectoryPattern.FindStringSubmatch(line); m != nil {
+			d.addPath(seen, WebPath{URL: m[1]})
+		}
+	}
+	return d
+}
+
+// addPath adds p unless its URL was already added
+func (d *ContentDiscovery) addPath(seen map[string]bool, p WebPath) {
+	if seen[p.URL] {
+		return
+	}
+	seen[p.URL] = true
+	d.Paths = append(d.Paths, p)
+}
+
+// addSubdomain adds s unless it was already added
+func (d *ContentDiscovery) addSubdomain(seen map[string]bool, s Subdomain) {
+	s.Subdomain = strings.ToLower(s.Subdomain)
+	if seen[s.Subdomain] {
+		return
+	}
+	seen[s.Subdomain] = true
+	d.Subdomains = append(d.Subdomains, s)
+}
+
+// splitList splits a comma or space separated list
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func atoi64(s string) int64 {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
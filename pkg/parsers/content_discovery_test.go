@@ -0,0 +1,110 @@
+package parsers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestParseGobusterOutput parses the output of every gobuster mode, with the
+// progress lines and line clearing escape sequences gobuster 3.6 writes
+// between results, and the vhost output of gobuster 3.1. Repeated results
+// are reported once.
+func TestParseGobusterOutput(t *testing.T) {
+	tests := []struct {
+		file    string
+		mode    string
+		baseURL string
+		want    *ContentDiscovery
+	}{
+		{
+			file:    "gobuster-3.6-dir.txt",
+			mode:    "dir",
+			baseURL: "http://10.0.0.5/",
+			want: &ContentDiscovery{Paths: []WebPath{
+				{URL: "http://10.0.0.5/.hta", Status: 403, Size: 286},
+				{URL: "http://10.0.0.5/.htaccess", Status: 403, Size: 291},
+				{URL: "http://10.0.0.5/cgi-bin/", Status: 403, Size: 290},
+				{URL: "http://10.0.0.5/dav", Status: 301, Size: 312, Redirect: "http://10.0.0.5/dav/"},
+				{URL: "http://10.0.0.5/index", Status: 200, Size: 891},
+				{URL: "http://10.0.0.5/index.php", Status: 200, Size: 891},
+				{URL: "http://10.0.0.5/phpMyAdmin", Status: 301, Size: 319, Redirect: "http://10.0.0.5/phpMyAdmin/"},
+				{URL: "http://10.0.0.5/phpinfo", Status: 200, Size: 48074},
+			}},
+		},
+		{
+			file: "gobuster-3.6-vhost.txt",
+			mode: "vhost",
+			want: &ContentDiscovery{Subdomains: []Subdomain{
+				{Subdomain: "dev.example.com", Status: 200, Size: 1432},
+				{Subdomain: "admin.example.com", Status: 401, Size: 459},
+			}},
+		},
+		{
+			file: "gobuster-3.1-vhost.txt",
+			mode: "vhost",
+			want: &ContentDiscovery{Subdomains: []Subdomain{
+				{Subdomain: "dev.example.com", Status: 200, Size: 1432},
+				{Subdomain: "mail.example.com", Status: 302},
+			}},
+		},
+		{
+			file: "gobuster-3.6-dns.txt",
+			mode: "dns",
+			want: &ContentDiscovery{Subdomains: []Subdomain{
+				{Subdomain: "www.example.com", IPs: []string{"93.184.215.14", "2606:2800:21f:cb07:6820:80da:af6b:8b2c"}},
+				{Subdomain: "mail.example.com", IPs: []string{"93.184.215.20"}},
+			}},
+		},
+		{
+			file: "gobuster-3.6-fuzz.txt",
+			mode: "fuzz",
+			want: &ContentDiscovery{Paths: []WebPath{
+				{URL: "http://10.0.0.5/index.php", Status: 200, Size: 891},
+				{URL: "http://10.0.0.5/phpinfo.php", Status: 200, Size: 48074},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ParseGobusterOutput(tt.mode, tt.baseURL, string(data))
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.MarshalIndent(got, "", "  ")
+				wantJSON, _ := json.MarshalIndent(tt.want, "", "  ")
+				t.Errorf("got\n%s\nwant\n%s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+// TestParseDirbOutput parses dirb output including the "--> Testing:"
+// progress it redraws with carriage returns
+func TestParseDirbOutput(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "dirb-2.22.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ContentDiscovery{Paths: []WebPath{
+		{URL: "http://10.0.0.5/cgi-bin/", Status: 403, Size: 288},
+		{URL: "http://10.0.0.5/dav/"},
+		{URL: "http://10.0.0.5/index", Status: 200, Size: 891},
+		{URL: "http://10.0.0.5/index.php", Status: 200, Size: 891},
+		{URL: "http://10.0.0.5/phpMyAdmin/"},
+		{URL: "http://10.0.0.5/phpinfo", Status: 200, Size: 48074},
+		{URL: "http://10.0.0.5/phpMyAdmin/ChangeLog", Status: 200, Size: 40540},
+		{URL: "http://10.0.0.5/phpMyAdmin/index.php", Status: 200, Size: 4145},
+	}}
+	got := ParseDirbOutput(string(data))
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.MarshalIndent(got, "", "  ")
+		wantJSON, _ := json.MarshalIndent(want, "", "  ")
+		t.Errorf("got\n%s\nwant\n%s", gotJSON, wantJSON)
+	}
+}
@@ -0,0 +1,461 @@
+package parsers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Enum4linuxReport is the SMB/NetBIOS inventory of a host found by
+// enum4linux or enum4linux-ng
+type Enum4linuxReport struct {
+	// Source is the tool that produced the report
+	Source         string                    `json:"source"`
+	Target         string                    `json:"target,omitempty"`
+	Workgroup      string                    `json:"workgroup,omitempty"`
+	Domain         string                    `json:"domain,omitempty"`
+	DomainSID      string                    `json:"domain_sid,omitempty"`
+	NetBIOSName    string                    `json:"netbios_name,omitempty"`
+	OS             *Enum4linuxOS             `json:"os,omitempty"`
+	Shares         []Enum4linuxShare         `json:"shares"`
+	Users          []Enum4linuxUser          `json:"users"`
+	Groups         []Enum4linuxGroup         `json:"groups"`
+	PasswordPolicy *Enum4linuxPasswordPolicy `json:"password_policy,omitempty"`
+	RIDAccounts    []Enum4linuxRIDAccount    `json:"rid_accounts"`
+}
+
+// Enum4linuxOS describes the operating system reported by the host
+type Enum4linuxOS struct {
+	OS         string `json:"os,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Server     string `json:"server,omitempty"`
+	PlatformID string `json:"platform_id,omitempty"`
+	ServerType string `json:"server_type,omitempty"`
+}
+
+// Enum4linuxShare is an SMB share and the access the scan had to it
+type Enum4linuxShare struct {
+	Name    string `json:"name"`
+	Type    string `json:"type,omitempty"`
+	Comment string `json:"comment,omitempty"`
+	Mapping string `json:"mapping,omitempty"`
+	Listing string `json:"listing,omitempty"`
+	Writing string `json:"writing,omitempty"`
+}
+
+// Enum4linuxUser is a user account
+type Enum4linuxUser struct {
+	Username    string `json:"username"`
+	RID         int    `json:"rid,omitempty"`
+	FullName    string `json:"full_name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Enum4linuxGroup is a builtin, local or domain group
+type Enum4linuxGroup struct {
+	Name    string   `json:"name"`
+	RID     int      `json:"rid,omitempty"`
+	Type    string   `json:"type,omitempty"`
+	Members []string `json:"members,omitempty"`
+}
+
+// Enum4linuxPasswordPolicy is the domain password and lockout policy.
+// Values are kept as reported, e.g. "30 minutes" or "None".
+type Enum4linuxPasswordPolicy struct {
+	MinLength        string `json:"min_length,omitempty"`
+	HistoryLength    string `json:"history_length,omitempty"`
+	MaxAge           string `json:"max_age,omitempty"`
+	MinAge           string `json:"min_age,omitempty"`
+	Complexity       *bool  `json:"complexity,omitempty"`
+	LockoutThreshold string `json:"lockout_threshold,omitempty"`
+	LockoutDuration  string `json:"lockout_duration,omitempty"`
+	LockoutWindow    string `json:"lockout_window,omitempty"`
+	ForceLogoff      string `json:"force_logoff,omitempty"`
+}
+
+// Enum4linuxRIDAccount is an account found by RID cycling
+type Enum4linuxRIDAccount struct {
+	SID  string `json:"sid"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+var (
+	e4lTargetPattern     = regexp.MustCompile(`^Target \.+ (\S+)`)
+	e4lWorkgroupPattern  = regexp.MustCompile(`Got domain/workgroup name: (\S+)`)
+	e4lNbtstatPattern    = regexp.MustCompile(`^(\S+)\s+<00>\s+-\s+(<GROUP>\s+)?\S\s+<ACTIVE>`)
+	e4lSmbclientOS       = regexp.MustCompile(`OS=\[([^\]]*)\]\s+Server=\[([^\]]*)\]`)
+	e4lUserPattern       = regexp.MustCompile(`^index: \S+ RID: (\S+) acb: \S+ Account: (.*?)\s+Name: ?(.*?)\s+Desc: ?(.*)$`)
+	e4lUserRIDPattern    = regexp.MustCompile(`^user:\[([^\]]*)\] rid:\[([^\]]*)\]`)
+	e4lGroupRIDPattern   = regexp.MustCompile(`^group:\[([^\]]*)\] rid:\[([^\]]*)\]`)
+	e4lMemberPattern     = regexp.MustCompile(`^Group:?\s*'?(.+?)'?\s+\(RID:\s*(\d+)\)\s+has member:\s*(.+)$`)
+	e4lShareMapPattern   = regexp.MustCompile(`^//[^/]+/(\S+)\s+Mapping: ([^,\s]+),?(?:\s+Listing: ([^,\s]+),?)?(?:\s+Writing: ([^,\s]+))?`)
+	e4lRIDPattern        = regexp.MustCompile(`^(S-1-[0-9-]+)\s+(.+?)\s+\((.+)\)$`)
+	e4lPolicyPattern     = regexp.MustCompile(`^(?:\[\+\]\s*)?([A-Za-z ]+?):\s*(.+)$`)
+	e4lSectionPattern    = regexp.MustCompile(`^=*\(?\s*([^=(\s].*?)\s*\)?=*$`)
+	e4lGroupTypePattern  = regexp.MustCompile(`Getting (builtin|local|domain) groups:`)
+	e4lShareHeadPattern  = regexp.MustCompile(`^Sharename\s+Type\s+Comment`)
+	e4lShareLinePattern  = regexp.MustCompile(`^(\S+)\s+(Disk|IPC|Printer)\s*(.*)$`)
+	e4lSrvinfoKeyPattern = regexp.MustCompile(`^(platform_id|os version|server type)\s*:\s*(.*)$`)
+)
+
+// ParseEnum4linuxOutput parses the text output of the classic enum4linux
+func ParseEnum4linuxOutput(output string) *Enum4linuxReport {
+	report := newEnum4linuxReport("enum4linux")
+	policy := &Enum4linuxPasswordPolicy{}
+	users := map[string]int{}
+	groups := map[string]int{}
+	shares := map[string]int{}
+	rids := map[string]bool{}
+
+	section := ""
+	groupType := ""
+	inShareList := false
+	osInfo := &Enum4linuxOS{}
+
+	for _, line := range cleanLines(output) {
+		// Section banners look like "=====( Users on 10.0.0.5 )=====" or,
+		// in older versions, "|    Users on 10.0.0.5    |" between two rules
+		// of "=" which do not start a section of their own
+		if strings.HasPrefix(line, "=") || strings.HasPrefix(line, "|") && strings.HasSuffix(line, "|") {
+			if strings.Trim(line, "=| ") == "" {
+				continue
+			}
+			if m := e4lSectionPattern.FindStringSubmatch(strings.Trim(line, "| ")); m != nil {
+				section = strings.ToLower(m[1])
+				inShareList = false
+			}
+			continue
+		}
+
+		switch {
+		case report.Target == "" && e4lTargetPattern.MatchString(line):
+			report.Target = e4lTargetPattern.FindStringSubmatch(line)[1]
+		case e4lWorkgroupPattern.MatchString(line):
+			report.Workgroup = e4lWorkgroupPattern.FindStringSubmatch(line)[1]
+		case strings.HasPrefix(line, "Domain Name:"):
+			report.Domain = strings.TrimSpace(strings.TrimPrefix(line, "Domain Name:"))
+		case strings.HasPrefix(line, "Domain Sid:"):
+			if sid := strings.TrimSpace(strings.TrimPrefix(line, "Domain Sid:")); strings.HasPrefix(sid, "S-") {
+				report.DomainSID = sid
+			}
+		case e4lNbtstatPattern.MatchString(line):
+			m := e4lNbtstatPattern.FindStringSubmatch(line)
+			if m[2] == "" && report.NetBIOSName == "" {
+				report.NetBIOSName = m[1]
+			} else if m[2] != "" && report.Workgroup == "" {
+				report.Workgroup = m[1]
+			}
+		case e4lSmbclientOS.MatchString(line):
+			m := e4lSmbclientOS.FindStringSubmatch(line)
+			osInfo.OS, osInfo.Server = m[1], m[2]
+		case strings.HasPrefix(section, "os information") && e4lSrvinfoKeyPattern.MatchString(line):
+			m := e4lSrvinfoKeyPattern.FindStringSubmatch(line)
+			switch m[1] {
+			case "platform_id":
+				osInfo.PlatformID = m[2]
+			case "os version":
+				osInfo.Version = m[2]
+			case "server type":
+				osInfo.ServerType = m[2]
+			}
+		case strings.HasPrefix(section, "os information") && !strings.HasPrefix(line, "[") && osInfo.Server == "":
+			// srvinfo: "NAME  Wk Sv PrQ Unx NT SNT comment (Samba 3.0.20-Debian)"
+			if i := strings.Index(line, "("); i >= 0 && strings.HasSuffix(line, ")") {
+				osInfo.Server = line[i+1 : len(line)-1]
+			}
+		case e4lUserPattern.MatchString(line):
+			m := e4lUserPattern.FindStringSubmatch(line)
+			user := enum4linuxUser(users, &report.Users, m[2])
+			user.RID = parseRID(m[1])
+			user.FullName = nullString(m[3])
+			user.Description = nullString(m[4])
+		case e4lUserRIDPattern.MatchString(line):
+			m := e4lUserRIDPattern.FindStringSubmatch(line)
+			enum4linuxUser(users, &report.Users, m[1]).RID = parseRID(m[2])
+		case e4lGroupTypePattern.MatchString(line):
+			groupType = e4lGroupTypePattern.FindStringSubmatch(line)[1]
+		case e4lGroupRIDPattern.MatchString(line):
+			m := e4lGroupRIDPattern.FindStringSubmatch(line)
+			group := enum4linuxGroup(groups, &report.Groups, m[1])
+			group.RID = parseRID(m[2])
+			if groupType != "" {
+				group.Type = groupType
+			}
+		case e4lMemberPattern.MatchString(line):
+			m := e4lMemberPattern.FindStringSubmatch(line)
+			group := enum4linuxGroup(groups, &report.Groups, m[1])
+			group.RID = parseRID(m[2])
+			group.Members = append(group.Members, m[3])
+		case e4lShareHeadPattern.MatchString(line):
+			inShareList = true
+		case inShareList && strings.HasPrefix(line, "---"):
+		case inShareList && e4lShareLinePattern.MatchString(line):
+			m := e4lShareLinePattern.FindStringSubmatch(line)
+			share := enum4linuxShare(shares, &report.Shares, m[1])
+			share.Type, share.Comment = m[2], m[3]
+		case e4lShareMapPattern.MatchString(line):
+			inShareList = false
+			m := e4lShareMapPattern.FindStringSubmatch(line)
+			share := enum4linuxShare(shares, &report.Shares, m[1])
+			share.Mapping, share.Listing, share.Writing = m[2], m[3], m[4]
+		case e4lRIDPattern.MatchString(line):
+			m := e4lRIDPattern.FindStringSubmatch(line)
+			if !strings.Contains(m[2], "*unknown*") && !rids[m[1]] {
+				rids[m[1]] = true
+				report.RIDAccounts = append(report.RIDAccounts, Enum4linuxRIDAccount{SID: m[1], Name: m[2], Type: m[3]})
+			}
+		case strings.Contains(section, "password policy") && e4lPolicyPattern.MatchString(line):
+			m := e4lPolicyPattern.FindStringSubmatch(line)
+			policy.set(m[1], m[2])
+		default:
+			inShareList = false
+		}
+	}
+
+	if *osInfo != (Enum4linuxOS{}) {
+		report.OS = osInfo
+	}
+	if *policy != (Enum4linuxPasswordPolicy{}) {
+		report.PasswordPolicy = policy
+	}
+	return report
+}
+
+// set records a password policy value reported under name. The first
+// value wins, as enum4linux repeats part of the policy from rpcclient.
+func (p *Enum4linuxPasswordPolicy) set(name, value string) {
+	var field *string
+	switch strings.ToLower(name) {
+	case "minimum password length":
+		field = &p.MinLength
+	case "password history length":
+		field = &p.HistoryLength
+	case "maximum password age":
+		field = &p.MaxAge
+	case "minimum password age":
+		field = &p.MinAge
+	case "account lockout threshold", "lockout threshold":
+		field = &p.LockoutThreshold
+	case "locked account duration", "lockout duration":
+		field = &p.LockoutDuration
+	case "reset account lockout counter", "lockout observation window":
+		field = &p.LockoutWindow
+	case "forced log off time", "force logoff time":
+		field = &p.ForceLogoff
+	case "domain password complex", "password complexity":
+		if p.Complexity == nil {
+			enabled := value == "1" || strings.EqualFold(value, "enabled") || strings.EqualFold(value, "true")
+			p.Complexity = &enabled
+		}
+		return
+	default:
+		return
+	}
+	if *field == "" {
+		*field = value
+	}
+}
+
+// enum4linuxNGJSON mirrors the parts of enum4linux-ng's JSON output that are kept
+type enum4linuxNGJSON struct {
+	Target struct {
+		Host      string `json:"host"`
+		Workgroup string `json:"workgroup"`
+	} `json:"target"`
+	Workgroup     string  `json:"workgroup"`
+	DomainSID     string  `json:"domain_sid"`
+	SMBDomainInfo infoMap `json:"smb_domain_info"`
+	OSInfo        infoMap `json:"os_info"`
+	Shares        map[string]struct {
+		Type    string `json:"type"`
+		Comment string `json:"comment"`
+		Access  struct {
+			Mapping string `json:"mapping"`
+			Listing string `json:"listing"`
+			Writing string `json:"writing"`
+		} `json:"access"`
+	} `json:"shares"`
+	Users map[string]struct {
+		Username    string `json:"username"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	} `json:"users"`
+	Groups map[string]struct {
+		Groupname string     `json:"groupname"`
+		Type      string     `json:"type"`
+		Members   stringList `json:"members"`
+	} `json:"groups"`
+	Policy map[string]map[string]json.RawMessage `json:"policy"`
+}
+
+// infoMap holds key/value information whose values may be strings,
+// numbers, booleans or null
+type infoMap map[string]interface{}
+
+// get returns the value of key as a string, or "" if it is missing or null
+func (m infoMap) get(key string) string {
+	if v, ok := m[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// ParseEnum4linuxNGJSONFile parses the enum4linux-ng JSON report at path
+func ParseEnum4linuxNGJSONFile(path string) (*Enum4linuxReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseEnum4linuxNGJSON(data)
+}
+
+// ParseEnum4linuxNGJSON parses the JSON output of enum4linux-ng
+func ParseEnum4linuxNGJSON(data []byte) (*Enum4linuxReport, error) {
+	var raw enum4linuxNGJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse enum4linux-ng JSON: %w", err)
+	}
+
+	report := newEnum4linuxReport("enum4linux-ng")
+	report.Target = raw.Target.Host
+	report.Workgroup = raw.Target.Workgroup
+	if report.Workgroup == "" {
+		report.Workgroup = raw.Workgroup
+	}
+	report.DomainSID = raw.DomainSID
+	report.Domain = raw.SMBDomainInfo.get("NetBIOS domain name")
+	report.NetBIOSName = raw.SMBDomainInfo.get("NetBIOS computer name")
+
+	if len(raw.OSInfo) > 0 {
+		report.OS = &Enum4linuxOS{
+			OS:         raw.OSInfo.get("OS"),
+			Version:    raw.OSInfo.get("OS version"),
+			Server:     raw.OSInfo.get("Native LAN manager"),
+			PlatformID: raw.OSInfo.get("Platform id"),
+			ServerType: raw.OSInfo.get("Server type"),
+		}
+	}
+
+	for name, s := range raw.Shares {
+		report.Shares = append(report.Shares, Enum4linuxShare{
+			Name:    name,
+			Type:    s.Type,
+			Comment: s.Comment,
+			Mapping: s.Access.Mapping,
+			Listing: s.Access.Listing,
+			Writing: s.Access.Writing,
+		})
+	}
+	sort.Slice(report.Shares, func(i, j int) bool { return report.Shares[i].Name < report.Shares[j].Name })
+
+	for rid, u := range raw.Users {
+		report.Users = append(report.Users, Enum4linuxUser{
+			Username:    u.Username,
+			RID:         parseRID(rid),
+			FullName:    u.Name,
+			Description: u.Description,
+		})
+	}
+	sort.Slice(report.Users, func(i, j int) bool { return report.Users[i].RID < report.Users[j].RID })
+
+	for rid, g := range raw.Groups {
+		report.Groups = append(report.Groups, Enum4linuxGroup{
+			Name:    g.Groupname,
+			RID:     parseRID(rid),
+			Type:    g.Type,
+			Members: g.Members,
+		})
+	}
+	sort.Slice(report.Groups, func(i, j int) bool { return report.Groups[i].RID < report.Groups[j].RID })
+
+	policy := &Enum4linuxPasswordPolicy{}
+	for _, section := range raw.Policy {
+		for name, value := range section {
+			if name == "Password properties" {
+				// A list of {"DOMAIN_PASSWORD_COMPLEX": bool} flags
+				var flags []map[string]bool
+				if json.Unmarshal(value, &flags) == nil {
+					for _, flag := range flags {
+						if complex, ok := flag["DOMAIN_PASSWORD_COMPLEX"]; ok {
+							policy.Complexity = &complex
+						}
+					}
+				}
+				continue
+			}
+			policy.set(name, strings.Trim(string(value), `"`))
+		}
+	}
+	if *policy != (Enum4linuxPasswordPolicy{}) {
+		report.PasswordPolicy = policy
+	}
+	return report, nil
+}
+
+// Summary returns a one line description of the inventory
+func (r *Enum4linuxReport) Summary() string {
+	name := r.Workgroup
+	if r.Domain != "" {
+		name = r.Domain
+	}
+	if name == "" {
+		name = "unknown domain"
+	}
+	return fmt.Sprintf("Enum4linux found %s: %d shares, %d users, %d groups, %d RID cycled accounts",
+		name, len(r.Shares), len(r.Users), len(r.Groups), len(r.RIDAccounts))
+}
+
+func newEnum4linuxReport(source string) *Enum4linuxReport {
+	return &Enum4linuxReport{
+		Source:      source,
+		Shares:      []Enum4linuxShare{},
+		Users:       []Enum4linuxUser{},
+		Groups:      []Enum4linuxGroup{},
+		RIDAccounts: []Enum4linuxRIDAccount{},
+	}
+}
+
+// enum4linuxUser returns the user named name, adding it if needed
+func enum4linuxUser(index map[string]int, list *[]Enum4linuxUser, name string) *Enum4linuxUser {
+	return &(*list)[findOrAdd(index, list, name, Enum4linuxUser{Username: name})]
+}
+
+// enum4linuxGroup returns the group named name, adding it if needed
+func enum4linuxGroup(index map[string]int, list *[]Enum4linuxGroup, name string) *Enum4linuxGroup {
+	return &(*list)[findOrAdd(index, list, name, Enum4linuxGroup{Name: name})]
+}
+
+// enum4linuxShare returns the share named name, adding it if needed
+func enum4linuxShare(index map[string]int, list *[]Enum4linuxShare, name string) *Enum4linuxShare {
+	return &(*list)[findOrAdd(index, list, name, Enum4linuxShare{Name: name})]
+}
+
+// findOrAdd returns the position in list of the element stored under key,
+// appending item if there is none
+func findOrAdd[T any](index map[string]int, list *[]T, key string, item T) int {
+	if i, ok := index[key]; ok {
+		return i
+	}
+	*list = append(*list, item)
+	index[key] = len(*list) - 1
+	return index[key]
+}
+
+// parseRID parses a decimal or 0x prefixed hexadecimal RID
+func parseRID(s string) int {
+	rid, _ := strconv.ParseInt(strings.TrimSpace(s), 0, 64)
+	return int(rid)
+}
+
+// nullString returns s unless it is "(null)"
+func nullString(s string) string {
+	if s == "(null)" {
+		return ""
+	}
+	return s
+}
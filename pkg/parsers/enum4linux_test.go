@@ -0,0 +1,129 @@
+package parsers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestParseEnum4linuxOutput parses the output of enum4linux 0.8.9, with
+// "|  Users on ... |" banners between rules of "=", and of 0.9.x, with
+// "====( Users on ... )====" banners
+func TestParseEnum4linuxOutput(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		file string
+		want *Enum4linuxReport
+	}{
+		{
+			file: "enum4linux-0.8.9.txt",
+			want: &Enum4linuxReport{
+				Source:      "enum4linux",
+				Target:      "10.0.0.5",
+				Workgroup:   "WORKGROUP",
+				Domain:      "WORKGROUP",
+				NetBIOSName: "METASPLOITABLE",
+				OS: &Enum4linuxOS{
+					OS:         "Unix",
+					Version:    "4.9",
+					Server:     "Samba 3.0.20-Debian",
+					PlatformID: "500",
+					ServerType: "0x9a03",
+				},
+				Shares: []Enum4linuxShare{
+					{Name: "print$", Type: "Disk", Comment: "Printer Drivers", Mapping: "DENIED", Listing: "N/A"},
+					{Name: "tmp", Type: "Disk", Comment: "oh noes!", Mapping: "OK", Listing: "OK"},
+					{Name: "opt", Type: "Disk", Mapping: "DENIED", Listing: "N/A"},
+					{Name: "IPC$", Type: "IPC", Comment: "IPC Service (metasploitable server (Samba 3.0.20-Debian))"},
+					{Name: "ADMIN$", Type: "IPC", Comment: "IPC Service (metasploitable server (Samba 3.0.20-Debian))", Mapping: "DENIED", Listing: "N/A"},
+				},
+				Users: []Enum4linuxUser{
+					{Username: "games", RID: 1010, FullName: "games"},
+					{Username: "nobody", RID: 501, FullName: "nobody"},
+					{Username: "msfadmin", RID: 3004, FullName: "msfadmin,,,"},
+				},
+				Groups: []Enum4linuxGroup{
+					{Name: "Domain Users", RID: 513, Type: "domain", Members: []string{`METASPLOITABLE\msfadmin`}},
+					{Name: "Domain Admins", RID: 512, Type: "domain"},
+				},
+				PasswordPolicy: &Enum4linuxPasswordPolicy{
+					MinLength:        "5",
+					HistoryLength:    "None",
+					MaxAge:           "Not Set",
+					MinAge:           "None",
+					Complexity:       &disabled,
+					LockoutThreshold: "None",
+					LockoutDuration:  "30 minutes",
+					LockoutWindow:    "30 minutes",
+					ForceLogoff:      "Not Set",
+				},
+				RIDAccounts: []Enum4linuxRIDAccount{
+					{SID: "S-1-5-21-1042354039-2475377354-766472396-500", Name: `METASPLOITABLE\Administrator`, Type: "Local User"},
+					{SID: "S-1-5-21-1042354039-2475377354-766472396-501", Name: `METASPLOITABLE\nobody`, Type: "Local User"},
+					{SID: "S-1-5-21-1042354039-2475377354-766472396-513", Name: `METASPLOITABLE\None`, Type: "Domain Group"},
+				},
+			},
+		},
+		{
+			file: "enum4linux-0.9.1.txt",
+			want: &Enum4linuxReport{
+				Source:      "enum4linux",
+				Target:      "10.0.0.5",
+				Workgroup:   "WORKGROUP",
+				Domain:      "WORKGROUP",
+				NetBIOSName: "FILESRV",
+				OS: &Enum4linuxOS{
+					Version:    "6.1",
+					PlatformID: "500",
+					ServerType: "0x809a03",
+				},
+				Shares: []Enum4linuxShare{
+					{Name: "print$", Type: "Disk", Comment: "Printer Drivers", Mapping: "DENIED", Listing: "N/A", Writing: "N/A"},
+					{Name: "public", Type: "Disk", Comment: "Public files", Mapping: "OK", Listing: "OK", Writing: "N/A"},
+					{Name: "IPC$", Type: "IPC", Comment: "IPC Service (Samba 4.17.12-Debian)", Mapping: "N/A", Listing: "N/A", Writing: "N/A"},
+				},
+				Users: []Enum4linuxUser{
+					{Username: "alice", RID: 1000, FullName: "Alice Smith"},
+					{Username: "bob", RID: 1001, Description: "backup operator"},
+				},
+				Groups: []Enum4linuxGroup{
+					{Name: "staff", RID: 1002, Type: "local", Members: []string{`FILESRV\alice`}},
+				},
+				PasswordPolicy: &Enum4linuxPasswordPolicy{
+					MinLength:        "5",
+					HistoryLength:    "None",
+					MaxAge:           "37 days 6 hours 21 minutes",
+					MinAge:           "None",
+					Complexity:       &disabled,
+					LockoutThreshold: "None",
+					LockoutDuration:  "30 minutes",
+					LockoutWindow:    "30 minutes",
+					ForceLogoff:      "37 days 6 hours 21 minutes",
+				},
+				RIDAccounts: []Enum4linuxRIDAccount{
+					{SID: "S-1-5-21-1111111111-2222222222-3333333333-501", Name: `FILESRV\nobody`, Type: "Local User"},
+					{SID: "S-1-5-21-1111111111-2222222222-3333333333-513", Name: `FILESRV\None`, Type: "Domain Group"},
+					{SID: "S-1-5-21-1111111111-2222222222-3333333333-1000", Name: `FILESRV\alice`, Type: "Local User"},
+					{SID: "S-1-22-1-1000", Name: `Unix User\alice`, Type: "Local User"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ParseEnum4linuxOutput(string(data))
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.MarshalIndent(got, "", "  ")
+				wantJSON, _ := json.MarshalIndent(tt.want, "", "  ")
+				t.Errorf("got\n%s\nwant\n%s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
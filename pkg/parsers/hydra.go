@@ -0,0 +1,47 @@
+package parsers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// HydraCredential is a valid login found by Hydra
+type HydraCredential struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Service  string `json:"service"`
+	Login    string `json:"login,omitempty"`
+	Password string `json:"password"`
+}
+
+// hydraResultPattern matches result lines such as
+// "[22][ssh] host: 10.0.0.1   login: root   password: toor"
+var hydraResultPattern = regexp.MustCompile(`^\[(\d+)\]\[([^\]]+)\]\s+host:\s+(\S+)(?:\s+login:\s+(\S*))?(?:\s+password:\s?(.*))?$`)
+
+// ParseHydraOutput extracts the valid credentials from Hydra's output or
+// from a file written with -o
+func ParseHydraOutput(output string) []HydraCredential {
+	creds := []HydraCredential{}
+	seen := map[HydraCredential]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		match := hydraResultPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		port, _ := strconv.Atoi(match[1])
+		cred := HydraCredential{
+			Host:     match[3],
+			Port:     port,
+			Service:  match[2],
+			Login:    match[4],
+			Password: match[5],
+		}
+		if !seen[cred] {
+			seen[cred] = true
+			creds = append(creds, cred)
+		}
+	}
+	return creds
+}
@@ -0,0 +1,48 @@
+package parsers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestParseHydraOutput parses Hydra's standard output and a file written
+// with -o, which Hydra appends to, so the same login can appear twice.
+// Services without user names, such as snmp, report only a password.
+func TestParseHydraOutput(t *testing.T) {
+	tests := []struct {
+		file string
+		want []HydraCredential
+	}{
+		{
+			file: "hydra-9.5-ssh.txt",
+			want: []HydraCredential{
+				{Host: "10.0.0.5", Port: 22, Service: "ssh", Login: "msfadmin", Password: "msfadmin"},
+				{Host: "10.0.0.5", Port: 22, Service: "ssh", Login: "user", Password: "user"},
+			},
+		},
+		{
+			file: "hydra-9.5-output.txt",
+			want: []HydraCredential{
+				{Host: "10.0.0.5", Port: 80, Service: "http-post-form", Login: "admin", Password: "correct horse battery staple"},
+				{Host: "10.0.0.5", Port: 80, Service: "http-post-form", Login: "guest", Password: ""},
+				{Host: "10.0.0.5", Port: 161, Service: "snmp", Password: "public"},
+				{Host: "10.0.0.5", Port: 161, Service: "snmp", Password: "private"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ParseHydraOutput(string(data))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package parsers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// JohnCracked is a password recovered by John the Ripper
+type JohnCracked struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password"`
+	Hash     string `json:"hash,omitempty"`
+}
+
+// JohnShowResult is the parsed output of "john --show"
+type JohnShowResult struct {
+	Cracked []JohnCracked `json:"cracked"`
+	Count   int           `json:"count"`
+	Left    int           `json:"left"`
+}
+
+// johnSummaryPattern matches "2 password hashes cracked, 1 left"
+var johnSummaryPattern = regexp.MustCompile(`^(\d+) password hash(?:es)? cracked, (\d+) left`)
+
+// ParseJohnShow parses the output of "john --show". Lines are of the form
+// "user:password[:more passwd fields]", or "?:password" for hashes without
+// a user name, which are "?N:password" once labelled with LabelJohnHashes.
+// hashes maps user names and labels to their hash, as read from the hash
+// file, and may be nil.
+func ParseJohnShow(output string, hashes map[string]string) *JohnShowResult {
+	result := &JohnShowResult{Cracked: []JohnCracked{}}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		if match := johnSummaryPattern.FindStringSubmatch(line); match != nil {
+			result.Count, _ = strconv.Atoi(match[1])
+			result.Left, _ = strconv.Atoi(match[2])
+			continue
+		}
+		user, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		hash := hashes[user]
+		if strings.HasPrefix(user, johnHashLabel) {
+			user = ""
+		}
+		result.Cracked = append(result.Cracked, JohnCracked{
+			Username: user,
+			Password: johnPassword(rest),
+			Hash:     hash,
+		})
+	}
+	return result
+}
+
+var (
+	// pwdumpFieldsPattern matches the fields john prints after the password
+	// of a pwdump line: ":rid:lm:nt:::"
+	pwdumpFieldsPattern = regexp.MustCompile(`:\d+:(?:[0-9A-Fa-f]{32}|NO PASSWORD\*+):(?:[0-9A-Fa-f]{32}|NO PASSWORD\*+):[^:]*:[^:]*:[^:]*$`)
+	// passwdFieldsPattern matches the fields john prints after the password
+	// of a passwd line: ":uid:gid:gecos:home:shell"
+	passwdFieldsPattern = regexp.MustCompile(`:\d+:\d+:[^:]*:/[^:]*:[^:]*$`)
+)
+
+// johnPassword returns the password of a "john --show" line without its
+// user name. Passwords may contain colons themselves, so the fields of the
+// passwd and pwdump formats that follow it are recognized from the end of
+// the line, and anything else is taken to be the password.
+func johnPassword(rest string) string {
+	for _, pattern := range []*regexp.Regexp{pwdumpFieldsPattern, passwdFieldsPattern} {
+		if loc := pattern.FindStringIndex(rest); loc != nil {
+			return rest[:loc[0]]
+		}
+	}
+	return rest
+}
+
+// johnHashLabel starts the user names LabelJohnHashes gives to bare hashes,
+// and is what "john --show" prints for bare hashes itself
+const johnHashLabel = "?"
+
+// LabelJohnHashes gives every line holding only a hash the user name "?1",
+// "?2" and so on, so that the output of "john --show" tells which hash a
+// password belongs to. It reports whether any line was labelled.
+func LabelJohnHashes(content string) (string, bool) {
+	lines := strings.Split(content, "\n")
+	labelled := false
+	for i, line := range lines {
+		hash := strings.TrimSpace(line)
+		if hash == "" || strings.Contains(hash, ":") {
+			continue
+		}
+		lines[i] = johnHashLabel + strconv.Itoa(i+1) + ":" + hash
+		labelled = true
+	}
+	return strings.Join(lines, "\n"), labelled
+}
+
+// ParseJohnHashFile maps user names to hashes for hash files in the
+// "user:hash[:...]" or pwdump "user:rid:lm:nt:::" format. Lines holding
+// only a hash are skipped.
+func ParseJohnHashFile(content string) map[string]string {
+	hashes := map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if len(fields) < 2 || fields[0] == "" || fields[1] == "" {
+			continue
+		}
+		hash := fields[1]
+		if _, err := strconv.Atoi(fields[1]); err == nil && len(fields) >= 4 {
+			hash = fields[3] // pwdump, use the NT hash
+		}
+		hashes[fields[0]] = hash
+	}
+	return hashes
+}
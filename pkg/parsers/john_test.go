@@ -0,0 +1,80 @@
+package parsers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestParseJohnShow parses "john --show" for hash files of bare hashes,
+// labelled with LabelJohnHashes, of unshadowed passwd entries and of pwdump
+// entries, with passwords containing colons
+func TestParseJohnShow(t *testing.T) {
+	tests := []struct {
+		hashFile string
+		showFile string
+		want     *JohnShowResult
+	}{
+		{
+			hashFile: "john-raw-md5.txt",
+			showFile: "john-raw-md5-show.txt",
+			want: &JohnShowResult{
+				Cracked: []JohnCracked{
+					{Password: "password", Hash: "5f4dcc3b5aa765d61d8327deb882cf99"},
+					{Password: "pa:ss", Hash: "77fd169859742c5dcbcb6001c2c66ac8"},
+					{Username: "alice", Password: "letmein", Hash: "0d107d09f5bbe40cade3de5c71e9e9b7"},
+				},
+				Count: 3,
+				Left:  1,
+			},
+		},
+		{
+			hashFile: "john-passwd.txt",
+			showFile: "john-passwd-show.txt",
+			want: &JohnShowResult{
+				Cracked: []JohnCracked{
+					{Username: "root", Password: "toor", Hash: "$6$Xa1sZq9c$fMI9g0ZVSaEms3QYJEsSPVInNVL/jdEDK.aCgCwDFEtzeb5/llrw7VGkH80sLy38CHlvgsqNhYa01/irpvrDN/"},
+					{Username: "msfadmin", Password: "msfadmin", Hash: "$6$kR3pQ0vB$NVsXSwldpq74/h8NxWspdQ4mK2b97HNRqKJGO7Ql8CWR.Ythev8jWhLtIaHyc04/akU7QJpPaVHI16cToy8qe/"},
+					{Username: "svc", Password: "pa:ss:1", Hash: "$6$N8dLw2Hy$Ywzc7r5PVGcmtKUb4riDmhn0M1jXZ2tqQgSBUXZwj9ovJTjs7y1sePKFQZGxdYB735c2jfBHwk/kQIZqetwnH1"},
+				},
+				Count: 3,
+				Left:  1,
+			},
+		},
+		{
+			hashFile: "john-pwdump.txt",
+			showFile: "john-pwdump-show.txt",
+			want: &JohnShowResult{
+				Cracked: []JohnCracked{
+					{Username: "Administrator", Password: "P@ss:w0rd", Hash: "58af24ddc81eb31154e0956df2af4d81"},
+					{Username: "Guest", Password: "", Hash: "31d6cfe0d16ae931b73c59d7e0c089c0"},
+					{Username: "jdoe", Password: "Summer2024", Hash: "349c161a3eb493c6347292a58528f923"},
+				},
+				Count: 3,
+				Left:  1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.showFile, func(t *testing.T) {
+			hashData, err := os.ReadFile(filepath.Join("testdata", tt.hashFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			showData, err := os.ReadFile(filepath.Join("testdata", tt.showFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, _ := LabelJohnHashes(string(hashData))
+			got := ParseJohnShow(string(showData), ParseJohnHashFile(content))
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.MarshalIndent(got, "", "  ")
+				wantJSON, _ := json.MarshalIndent(tt.want, "", "  ")
+				t.Errorf("got\n%s\nwant\n%s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package parsers
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// NmapRun is the parsed result of an Nmap XML report
+type NmapRun struct {
+	Args      string     `json:"args,omitempty"`
+	Version   string     `json:"version,omitempty"`
+	StartTime int64      `json:"start_time,omitempty"`
+	Hosts     []NmapHost `json:"hosts"`
+	HostsUp   int        `json:"hosts_up"`
+	HostsDown int        `json:"hosts_down"`
+	Summary   string     `json:"summary,omitempty"`
+	// Complete is false when the report ended early, e.g. because the scan
+	// timed out; Hosts then holds every host reported until then
+	Complete bool `json:"complete"`
+}
+
+// NmapHost is a scanned host
+type NmapHost struct {
+	Status    string         `json:"status"`
+	Addresses []NmapAddress  `json:"addresses"`
+	Hostnames []NmapHostname `json:"hostnames,omitempty"`
+	Ports     []NmapPort     `json:"ports,omitempty"`
+	OSMatches []NmapOSMatch  `json:"os_matches,omitempty"`
+	Scripts   []NmapScript   `json:"scripts,omitempty"`
+}
+
+// NmapAddress is an address of a host
+type NmapAddress struct {
+	Addr     string `json:"addr" xml:"addr,attr"`
+	AddrType string `json:"addr_type" xml:"addrtype,attr"`
+	Vendor   string `json:"vendor,omitempty" xml:"vendor,attr"`
+}
+
+// NmapHostname is a name of a host
+type NmapHostname struct {
+	Name string `json:"name" xml:"name,attr"`
+	Type string `json:"type,omitempty" xml:"type,attr"`
+}
+
+// NmapPort is a scanned port and the service detected on it
+type NmapPort struct {
+	Protocol  string       `json:"protocol"`
+	Port      int          `json:"port"`
+	State     string       `json:"state"`
+	Reason    string       `json:"reason,omitempty"`
+	Service   string       `json:"service,omitempty"`
+	Product   string       `json:"product,omitempty"`
+	Version   string       `json:"version,omitempty"`
+	ExtraInfo string       `json:"extra_info,omitempty"`
+	Tunnel    string       `json:"tunnel,omitempty"`
+	CPEs      []string     `json:"cpes,omitempty"`
+	Scripts   []NmapScript `json:"scripts,omitempty"`
+}
+
+// NmapScript is the output of an NSE script
+type NmapScript struct {
+	ID     string `json:"id" xml:"id,attr"`
+	Output string `json:"output" xml:"output,attr"`
+}
+
+// NmapOSMatch is a guess of the host's operating system
+type NmapOSMatch struct {
+	Name     string `json:"name" xml:"name,attr"`
+	Accuracy int    `json:"accuracy" xml:"accuracy,attr"`
+}
+
+// nmapXMLHost mirrors the <host> element of the XML report
+type nmapXMLHost struct {
+	Status struct {
+		State string `xml:"state,attr"`
+	} `xml:"status"`
+	Addresses []NmapAddress  `xml:"address"`
+	Hostnames []NmapHostname `xml:"hostnames>hostname"`
+	Ports     []struct {
+		Protocol string `xml:"protocol,attr"`
+		PortID   int    `xml:"portid,attr"`
+		State    struct {
+			State  string `xml:"state,attr"`
+			Reason string `xml:"reason,attr"`
+		} `xml:"state"`
+		Service struct {
+			Name      string   `xml:"name,attr"`
+			Product   string   `xml:"product,attr"`
+			Version   string   `xml:"version,attr"`
+			ExtraInfo string   `xml:"extrainfo,attr"`
+			Tunnel    string   `xml:"tunnel,attr"`
+			CPEs      []string `xml:"cpe"`
+		} `xml:"service"`
+		Scripts []NmapScript `xml:"script"`
+	} `xml:"ports>port"`
+	OSMatches   []NmapOSMatch `xml:"os>osmatch"`
+	HostScripts []NmapScript  `xml:"hostscript>script"`
+}
+
+// ParseNmapXMLFile parses the Nmap XML report at path
+func ParseNmapXMLFile(path string) (*NmapRun, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseNmapXML(f)
+}
+
+// ParseNmapXML parses an Nmap XML report. Hosts are decoded one at a time,
+// so a report cut off by a timeout still yields every complete host.
+func ParseNmapXML(r io.Reader) (*NmapRun, error) {
+	run := &NmapRun{Hosts: []NmapHost{}}
+	decoder := xml.NewDecoder(r)
+	seenRoot := false
+
+loop:
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if !seenRoot {
+				return nil, fmt.Errorf("failed to parse nmap XML: %w", err)
+			}
+			// Truncated report, keep what was parsed so far
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break loop
+			}
+			return run, fmt.Errorf("failed to parse nmap XML: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "nmaprun":
+			seenRoot = true
+			for _, attr := range start.Attr {
+				switch attr.Name.Local {
+				case "args":
+					run.Args = attr.Value
+				case "version":
+					run.Version = attr.Value
+				case "start":
+					run.StartTime, _ = strconv.ParseInt(attr.Value, 10, 64)
+				}
+			}
+		case "host":
+			var host nmapXMLHost
+			if err := decoder.DecodeElement(&host, &start); err != nil {
+				// The host element was cut off
+				break loop
+			}
+			run.Hosts = append(run.Hosts, convertNmapHost(host))
+		case "finished":
+			run.Complete = true
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "summary" {
+					run.Summary = attr.Value
+				}
+			}
+		case "hosts":
+			for _, attr := range start.Attr {
+				switch attr.Name.Local {
+				case "up":
+					run.HostsUp, _ = strconv.Atoi(attr.Value)
+				case "down":
+					run.HostsDown, _ = strconv.Atoi(attr.Value)
+				}
+			}
+		}
+	}
+
+	if !seenRoot {
+		return nil, fmt.Errorf("failed to parse nmap XML: no nmaprun element")
+	}
+	if !run.Complete {
+		// runstats is missing, count the hosts ourselves
+		run.HostsUp, run.HostsDown = 0, 0
+		for _, host := range run.Hosts {
+			if host.Status == "up" {
+				run.HostsUp++
+			} else {
+				run.HostsDown++
+			}
+		}
+	}
+	return run, nil
+}
+
+// convertNmapHost flattens the XML representation of a host
+func convertNmapHost(h nmapXMLHost) NmapHost {
+	host := NmapHost{
+		Status:    h.Status.State,
+		Addresses: h.Addresses,
+		Hostnames: h.Hostnames,
+		OSMatches: h.OSMatches,
+		Scripts:   h.HostScripts,
+	}
+	for _, p := range h.Ports {
+		host.Ports = append(host.Ports, NmapPort{
+			Protocol:  p.Protocol,
+			Port:      p.PortID,
+			State:     p.State.State,
+			Reason:    p.State.Reason,
+			Service:   p.Service.Name,
+			Product:   p.Service.Product,
+			Version:   p.Service.Version,
+			ExtraInfo: p.Service.ExtraInfo,
+			Tunnel:    p.Service.Tunnel,
+			CPEs:      p.Service.CPEs,
+			Scripts:   p.Scripts,
+		})
+	}
+	return host
+}
@@ -0,0 +1,115 @@
+package parsers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseNmapXMLFile parses a complete nmap -sV -sC -O report and the
+// same report cut off in the middle of a host, as left behind by a scan
+// that timed out
+func TestParseNmapXMLFile(t *testing.T) {
+	const args = "nmap -sV -sC -O -oX /tmp/mcp-kali-server/runs/3f9c2a7d1e4b8a60/nmap.xml 10.0.0.5 10.0.0.6"
+	metasploitable := NmapHost{
+		Status: "up",
+		Addresses: []NmapAddress{
+			{Addr: "10.0.0.5", AddrType: "ipv4"},
+			{Addr: "08:00:27:3A:91:5C", AddrType: "mac", Vendor: "Oracle VirtualBox virtual NIC"},
+		},
+		Hostnames: []NmapHostname{{Name: "metasploitable.lab", Type: "PTR"}},
+		Ports: []NmapPort{
+			{
+				Protocol: "tcp", Port: 21, State: "open", Reason: "syn-ack",
+				Service: "ftp", Product: "vsftpd", Version: "2.3.4",
+				CPEs:    []string{"cpe:/a:vsftpd:vsftpd:2.3.4"},
+				Scripts: []NmapScript{{ID: "ftp-anon", Output: "Anonymous FTP login allowed (FTP code 230)"}},
+			},
+			{
+				Protocol: "tcp", Port: 22, State: "open", Reason: "syn-ack",
+				Service: "ssh", Product: "OpenSSH", Version: "4.7p1 Debian 8ubuntu1", ExtraInfo: "protocol 2.0",
+				CPEs: []string{"cpe:/a:openbsd:openssh:4.7p1", "cpe:/o:linux:linux_kernel"},
+			},
+			{
+				Protocol: "tcp", Port: 80, State: "open", Reason: "syn-ack",
+				Service: "http", Product: "Apache httpd", Version: "2.2.8", ExtraInfo: "(Ubuntu) DAV/2",
+				CPEs: []string{"cpe:/a:apache:http_server:2.2.8"},
+				Scripts: []NmapScript{
+					{ID: "http-title", Output: "Metasploitable2 - Linux"},
+					{ID: "http-server-header", Output: "Apache/2.2.8 (Ubuntu) DAV/2"},
+				},
+			},
+			{
+				Protocol: "tcp", Port: 443, State: "open", Reason: "syn-ack",
+				Service: "http", Product: "Apache httpd", Version: "2.2.8", Tunnel: "ssl",
+				CPEs: []string{"cpe:/a:apache:http_server:2.2.8"},
+			},
+		},
+		OSMatches: []NmapOSMatch{{Name: "Linux 2.6.9 - 2.6.33", Accuracy: 100}},
+		Scripts: []NmapScript{
+			{ID: "smb-os-discovery", Output: "\n  OS: Unix (Samba 3.0.20-Debian)\n  NetBIOS computer name: \n  Workgroup: WORKGROUP\\x00\n"},
+			{ID: "nbstat", Output: "NetBIOS name: METASPLOITABLE, NetBIOS user: <unknown>, NetBIOS MAC: <unknown> (unknown)"},
+		},
+	}
+
+	tests := []struct {
+		file string
+		want *NmapRun
+	}{
+		{
+			file: "nmap-7.94.xml",
+			want: &NmapRun{
+				Args:      args,
+				Version:   "7.94SVN",
+				StartTime: 1792357452,
+				Hosts: []NmapHost{
+					metasploitable,
+					{Status: "down", Addresses: []NmapAddress{{Addr: "10.0.0.6", AddrType: "ipv4"}}},
+				},
+				HostsUp:   1,
+				HostsDown: 1,
+				Summary:   "Nmap done at Sat Oct 17 21:04:41 2026; 2 IP addresses (1 host up) scanned in 29.31 seconds",
+				Complete:  true,
+			},
+		},
+		{
+			file: "nmap-7.94-timeout.xml",
+			want: &NmapRun{
+				Args:      args,
+				Version:   "7.94SVN",
+				StartTime: 1792357452,
+				Hosts:     []NmapHost{metasploitable},
+				HostsUp:   1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := ParseNmapXMLFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.MarshalIndent(got, "", "  ")
+				wantJSON, _ := json.MarshalIndent(tt.want, "", "  ")
+				t.Errorf("got\n%s\nwant\n%s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+// TestParseNmapXMLInvalid rejects output that is not an nmap XML report
+func TestParseNmapXMLInvalid(t *testing.T) {
+	if _, err := ParseNmapXMLFile(filepath.Join("testdata", "nmap-7.94.xml.missing")); !os.IsNotExist(err) {
+		t.Errorf("missing report: got %v, want a not exist error", err)
+	}
+	for _, input := range []string{"", "Starting Nmap 7.94SVN ( https://nmap.org )\n", "<html></html>"} {
+		if _, err := ParseNmapXML(strings.NewReader(input)); err == nil {
+			t.Errorf("ParseNmapXML(%q) did not fail", input)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package parsers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Severities ordered from most to least severe
+var Severities = []string{"critical", "high", "medium", "low", "info", "unknown"}
+
+// NucleiFinding is a single result reported by a Nuclei template
+type NucleiFinding struct {
+	TemplateID       string   `json:"template_id"`
+	Name             string   `json:"name"`
+	Severity         string   `json:"severity"`
+	Type             string   `json:"type,omitempty"`
+	Host             string   `json:"host,omitempty"`
+	MatchedAt        string   `json:"matched_at,omitempty"`
+	MatcherName      string   `json:"matcher_name,omitempty"`
+	ExtractedResults []string `json:"extracted_results,omitempty"`
+	CVEs             []string `json:"cves,omitempty"`
+	CWEs             []string `json:"cwes,omitempty"`
+	CVSSScore        float64  `json:"cvss_score,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+	Description      string   `json:"description,omitempty"`
+	CurlCommand      string   `json:"curl_command,omitempty"`
+}
+
+// NucleiReport holds the findings of a Nuclei scan grouped by severity
+type NucleiReport struct {
+	Total    int                        `json:"total"`
+	Counts   map[string]int             `json:"counts"`
+	Findings map[string][]NucleiFinding `json:"findings"`
+}
+
+// nucleiJSONResult mirrors a line of Nuclei's JSONL output
+type nucleiJSONResult struct {
+	TemplateID string `json:"template-id"`
+	Info       struct {
+		Name           string     `json:"name"`
+		Severity       string     `json:"severity"`
+		Description    string     `json:"description"`
+		Tags           stringList `json:"tags"`
+		Classification struct {
+			CVEID     stringList `json:"cve-id"`
+			CWEID     stringList `json:"cwe-id"`
+			CVSSScore float64    `json:"cvss-score"`
+		} `json:"classification"`
+	} `json:"info"`
+	Type             string     `json:"type"`
+	Host             string     `json:"host"`
+	MatchedAt        string     `json:"matched-at"`
+	MatcherName      string     `json:"matcher-name"`
+	ExtractedResults stringList `json:"extracted-results"`
+	CurlCommand      string     `json:"curl-command"`
+}
+
+// stringList accepts both a JSON string (comma separated) and an array of
+// strings, as Nuclei uses either depending on version and template
+type stringList []string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*l = list
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*l = nil
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// ParseNucleiJSONLFile parses the Nuclei JSONL export at path
+func ParseNucleiJSONLFile(path string) (*NucleiReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseNucleiJSONL(f)
+}
+
+// ParseNucleiJSONL parses Nuclei JSONL output. Lines that are not JSON
+// results are skipped.
+func ParseNucleiJSONL(r io.Reader) (*NucleiReport, error) {
+	report := &NucleiReport{
+		Counts:   map[string]int{},
+		Findings: map[string][]NucleiFinding{},
+	}
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && line[0] == '{' {
+			var result nucleiJSONResult
+			if jsonErr := json.Unmarshal(line, &result); jsonErr == nil && result.TemplateID != "" {
+				report.add(result)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to read nuclei output: %w", err)
+		}
+	}
+	return report, nil
+}
+
+// add records a result under its severity
+func (r *NucleiReport) add(result nucleiJSONResult) {
+	severity := strings.ToLower(result.Info.Severity)
+	if severity == "" {
+		severity = "unknown"
+	}
+	finding := NucleiFinding{
+		TemplateID:       result.TemplateID,
+		Name:             result.Info.Name,
+		Severity:         severity,
+		Type:             result.Type,
+		Host:             result.Host,
+		MatchedAt:        result.MatchedAt,
+		MatcherName:      result.MatcherName,
+		ExtractedResults: result.ExtractedResults,
+		CVEs:             result.Info.Classification.CVEID,
+		CWEs:             result.Info.Classification.CWEID,
+		CVSSScore:        result.Info.Classification.CVSSScore,
+		Tags:             result.Info.Tags,
+		Description:      strings.TrimSpace(result.Info.Description),
+		CurlCommand:      result.CurlCommand,
+	}
+	r.Findings[severity] = append(r.Findings[severity], finding)
+	r.Counts[severity]++
+	r.Total++
+}
+
+// Summary returns a one line description of the findings by severity
+func (r *NucleiReport) Summary() string {
+	if r.Total == 0 {
+		return "Nuclei found no findings"
+	}
+	var parts []string
+	for _, severity := range Severities {
+		if count := r.Counts[severity]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count, severity))
+		}
+	}
+	return fmt.Sprintf("Nuclei found %d findings: %s", r.Total, strings.Join(parts, ", "))
+}
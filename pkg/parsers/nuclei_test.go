@@ -0,0 +1,130 @@
+package parsers
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestParseNucleiJSONLFile parses the JSONL export of nuclei 3.x, where tags
+// and classification IDs are arrays, and of 2.9, where they can be comma
+// separated strings
+func TestParseNucleiJSONLFile(t *testing.T) {
+	tests := []struct {
+		file    string
+		want    *NucleiReport
+		summary string
+	}{
+		{
+			file: "nuclei-3.2.jsonl",
+			want: &NucleiReport{
+				Total:  4,
+				Counts: map[string]int{"high": 1, "low": 1, "info": 2},
+				Findings: map[string][]NucleiFinding{
+					"high": {{
+						TemplateID:  "CVE-2021-41773",
+						Name:        "Apache 2.4.49 - Path Traversal and Remote Code Execution",
+						Severity:    "high",
+						Type:        "http",
+						Host:        "10.0.0.7",
+						MatchedAt:   "http://10.0.0.7/icons/.%2e/%2e%2e/%2e%2e/%2e%2e/etc/passwd",
+						CVEs:        []string{"cve-2021-41773"},
+						CWEs:        []string{"cwe-22"},
+						CVSSScore:   7.5,
+						Tags:        []string{"cve", "cve2021", "lfi", "rce", "apache", "misconfig", "traversal", "kev"},
+						Description: "A flaw was found in a change made to path normalization in Apache HTTP Server 2.4.49.",
+						CurlCommand: "curl -X 'GET' -d '' -H 'Host: 10.0.0.7' 'http://10.0.0.7/icons/.%2e/%2e%2e/%2e%2e/%2e%2e/etc/passwd'",
+					}},
+					"low": {{
+						TemplateID:       "phpinfo-files",
+						Name:             "PHPInfo Page - Detect",
+						Severity:         "low",
+						Type:             "http",
+						Host:             "10.0.0.7",
+						MatchedAt:        "http://10.0.0.7/phpinfo.php",
+						ExtractedResults: []string{"7.4.3"},
+						CWEs:             []string{"cwe-200"},
+						Tags:             []string{"config", "exposure", "phpinfo"},
+						Description:      "PHPInfo page was detected. The output of the phpinfo() command can reveal detailed system information.",
+						CurlCommand:      "curl -X 'GET' -d '' -H 'Host: 10.0.0.7' 'http://10.0.0.7/phpinfo.php'",
+					}},
+					"info": {
+						{
+							TemplateID:  "tech-detect",
+							Name:        "Wappalyzer Technology Detection",
+							Severity:    "info",
+							Type:        "http",
+							Host:        "10.0.0.7",
+							MatchedAt:   "http://10.0.0.7",
+							MatcherName: "apache",
+							Tags:        []string{"tech"},
+							CurlCommand: "curl -X 'GET' -d '' -H 'Host: 10.0.0.7' 'http://10.0.0.7'",
+						},
+						{
+							TemplateID: "caa-fingerprint",
+							Name:       "CAA Fingerprint",
+							Severity:   "info",
+							Type:       "dns",
+							Host:       "10.0.0.7",
+							MatchedAt:  "10.0.0.7",
+							Tags:       []string{"dns", "caa"},
+						},
+					},
+				},
+			},
+			summary: "Nuclei found 4 findings: 1 high, 1 low, 2 info",
+		},
+		{
+			file: "nuclei-2.9.jsonl",
+			want: &NucleiReport{
+				Total:  2,
+				Counts: map[string]int{"critical": 1, "info": 1},
+				Findings: map[string][]NucleiFinding{
+					"critical": {{
+						TemplateID:  "CVE-2017-5638",
+						Name:        "Apache Struts 2 - Remote Command Execution",
+						Severity:    "critical",
+						Type:        "http",
+						Host:        "http://10.0.0.8:8080",
+						MatchedAt:   "http://10.0.0.8:8080/struts2-showcase/",
+						CVEs:        []string{"cve-2017-5638"},
+						CWEs:        []string{"cwe-20"},
+						CVSSScore:   10,
+						Tags:        []string{"cve", "cve2017", "apache", "kev", "msf", "struts", "rce"},
+						Description: "Jakarta Multipart parser in Apache Struts 2 2.3.x before 2.3.32 and 2.5.x before 2.5.10.1 mishandles file upload.",
+						CurlCommand: `curl -X 'GET' -H 'Content-Type: %{#context["com.opensymphony.xwork2.dispatcher.HttpServletResponse"].addHeader("X-Hacker","Bounty Plz")}.multipart/form-data' 'http://10.0.0.8:8080/struts2-showcase/'`,
+					}},
+					"info": {{
+						TemplateID:  "http-missing-security-headers",
+						Name:        "HTTP Missing Security Headers",
+						Severity:    "info",
+						Type:        "http",
+						Host:        "http://10.0.0.8:8080",
+						MatchedAt:   "http://10.0.0.8:8080",
+						MatcherName: "x-frame-options",
+						Tags:        []string{"misconfig", "headers", "generic"},
+					}},
+				},
+			},
+			summary: "Nuclei found 2 findings: 1 critical, 1 info",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := ParseNucleiJSONLFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.MarshalIndent(got, "", "  ")
+				wantJSON, _ := json.MarshalIndent(tt.want, "", "  ")
+				t.Errorf("got\n%s\nwant\n%s", gotJSON, wantJSON)
+			}
+			if summary := got.Summary(); summary != tt.summary {
+				t.Errorf("summary %q, want %q", summary, tt.summary)
+			}
+		})
+	}
+}
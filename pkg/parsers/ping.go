@@ -0,0 +1,130 @@
+package parsers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// PingResult is the parsed output of a ping run against one target
+type PingResult struct {
+	Target      string      `json:"target"`
+	Address     string      `json:"address,omitempty"`
+	Sent        int         `json:"sent"`
+	Received    int         `json:"received"`
+	LossPercent float64     `json:"loss_percent"`
+	MinMS       float64     `json:"min_ms,omitempty"`
+	AvgMS       float64     `json:"avg_ms,omitempty"`
+	MaxMS       float64     `json:"max_ms,omitempty"`
+	MdevMS      float64     `json:"mdev_ms,omitempty"`
+	Replies     []PingReply `json:"replies,omitempty"`
+	Reachable   bool        `json:"reachable"`
+	// OSHint is a guess of the OS family from the initial TTL of replies
+	OSHint string `json:"os_hint,omitempty"`
+	// Error is set when ping could not be run against the target
+	Error string `json:"error,omitempty"`
+}
+
+// PingReply is a single echo reply
+type PingReply struct {
+	Seq    int     `json:"seq"`
+	TTL    int     `json:"ttl"`
+	TimeMS float64 `json:"time_ms"`
+}
+
+// PingSweep holds the results of pinging several targets
+type PingSweep struct {
+	Total     int          `json:"total"`
+	Reachable int          `json:"reachable"`
+	Failed    int          `json:"failed,omitempty"` // hosts that could not be pinged
+	Hosts     []PingResult `json:"hosts"`
+}
+
+var (
+	// The header is "PING host (address)", "PING address(address)" from
+	// iputils ping6, or "PING host(host (address))" for IPv6 hostnames
+	pingHeaderPattern = regexp.MustCompile(`^PING \S+? ?\((?:[^()]* \()?([^()]+)\)`)
+	// Replies are "bytes from address: icmp_seq=" or "seq=" on BusyBox, where
+	// IPv6 addresses contain colons themselves
+	pingReplyPattern = regexp.MustCompile(`bytes from .+: (?:icmp_)?seq=(\d+) ttl=(\d+) time[=<]([\d.]+) ms`)
+	pingStatsPattern = regexp.MustCompile(`(\d+) packets transmitted, (\d+) (?:packets )?received.*?([\d.]+)% packet loss`)
+	pingRTTPattern   = regexp.MustCompile(`= ([\d.]+)/([\d.]+)/([\d.]+)(?:/([\d.]+))? ms`)
+)
+
+// ParsePingOutput parses the output of iputils or BusyBox ping
+func ParsePingOutput(target, output string) *PingResult {
+	result := &PingResult{Target: target}
+	for _, line := range cleanLines(output) {
+		if m := pingHeaderPattern.FindStringSubmatch(line); m != nil {
+			result.Address = m[1]
+		} else if m := pingReplyPattern.FindStringSubmatch(line); m != nil {
+			result.Replies = append(result.Replies, PingReply{
+				Seq:    atoi(m[1]),
+				TTL:    atoi(m[2]),
+				TimeMS: parseFloat(m[3]),
+			})
+		} else if m := pingStatsPattern.FindStringSubmatch(line); m != nil {
+			result.Sent = atoi(m[1])
+			result.Received = atoi(m[2])
+			result.LossPercent = parseFloat(m[3])
+		} else if m := pingRTTPattern.FindStringSubmatch(line); m != nil {
+			result.MinMS = parseFloat(m[1])
+			result.AvgMS = parseFloat(m[2])
+			result.MaxMS = parseFloat(m[3])
+			result.MdevMS = parseFloat(m[4])
+		}
+	}
+	if result.Received == 0 {
+		// Statistics are missing when ping was interrupted
+		result.Received = len(result.Replies)
+	}
+	result.Reachable = result.Received > 0
+	if len(result.Replies) > 0 {
+		result.OSHint = osHintFromTTL(result.Replies[0].TTL)
+	}
+	return result
+}
+
+// Summary returns a one line description of the result
+func (r *PingResult) Summary() string {
+	if r.Error != "" {
+		return fmt.Sprintf("%s: error: %s", r.Target, r.Error)
+	}
+	if !r.Reachable {
+		return fmt.Sprintf("%s is unreachable (%d packets sent, %g%% loss)", r.Target, r.Sent, r.LossPercent)
+	}
+	summary := fmt.Sprintf("%s is reachable: %d/%d replies, %g%% loss, avg %.3f ms", r.Target, r.Received, r.Sent, r.LossPercent, r.AvgMS)
+	if len(r.Replies) > 0 {
+		summary += fmt.Sprintf(", ttl %d", r.Replies[0].TTL)
+	}
+	return summary
+}
+
+// Summary returns a one line description of the sweep
+func (s *PingSweep) Summary() string {
+	summary := fmt.Sprintf("Ping sweep: %d of %d hosts reachable", s.Reachable, s.Total)
+	if s.Failed > 0 {
+		summary += fmt.Sprintf(", %d could not be pinged", s.Failed)
+	}
+	return summary
+}
+
+// osHintFromTTL guesses the OS family from the initial TTL a reply
+// started with, which is the next common default at or above ttl
+func osHintFromTTL(ttl int) string {
+	switch {
+	case ttl <= 0:
+		return ""
+	case ttl <= 64:
+		return "Linux/Unix"
+	case ttl <= 128:
+		return "Windows"
+	default:
+		return "network device"
+	}
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
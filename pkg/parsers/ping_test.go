@@ -0,0 +1,115 @@
+package parsers
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParsePingOutput parses the output of iputils ping over IPv4 and IPv6
+// and of BusyBox ping
+func TestParsePingOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		output string
+		want   *PingResult
+	}{
+		{
+			name:   "iputils",
+			target: "example.com",
+			output: `PING example.com (93.184.216.34) 56(84) bytes of data.
+64 bytes from 93.184.216.34 (93.184.216.34): icmp_seq=1 ttl=56 time=11.2 ms
+64 bytes from 93.184.216.34 (93.184.216.34): icmp_seq=2 ttl=56 time=11.6 ms
+
+--- example.com ping statistics ---
+2 packets transmitted, 2 received, 0% packet loss, time 1001ms
+rtt min/avg/max/mdev = 11.200/11.400/11.600/0.200 ms
+`,
+			want: &PingResult{
+				Target: "example.com", Address: "93.184.216.34",
+				Sent: 2, Received: 2,
+				MinMS: 11.2, AvgMS: 11.4, MaxMS: 11.6, MdevMS: 0.2,
+				Replies:   []PingReply{{Seq: 1, TTL: 56, TimeMS: 11.2}, {Seq: 2, TTL: 56, TimeMS: 11.6}},
+				Reachable: true, OSHint: "Linux/Unix",
+			},
+		},
+		{
+			name:   "iputils ping6",
+			target: "2001:db8::1",
+			output: `PING 2001:db8::1(2001:db8::1) 56 data bytes
+64 bytes from 2001:db8::1: icmp_seq=1 ttl=64 time=0.045 ms
+64 bytes from 2001:db8::1: icmp_seq=2 ttl=64 time=0.051 ms
+
+--- 2001:db8::1 ping statistics ---
+2 packets transmitted, 2 received, 0% packet loss, time 1015ms
+rtt min/avg/max/mdev = 0.045/0.048/0.051/0.003 ms
+`,
+			want: &PingResult{
+				Target: "2001:db8::1", Address: "2001:db8::1",
+				Sent: 2, Received: 2,
+				MinMS: 0.045, AvgMS: 0.048, MaxMS: 0.051, MdevMS: 0.003,
+				Replies:   []PingReply{{Seq: 1, TTL: 64, TimeMS: 0.045}, {Seq: 2, TTL: 64, TimeMS: 0.051}},
+				Reachable: true, OSHint: "Linux/Unix",
+			},
+		},
+		{
+			name:   "iputils ping6 hostname",
+			target: "ipv6.example.com",
+			output: `PING ipv6.example.com(ipv6.example.com (2001:db8::2)) 56 data bytes
+64 bytes from ipv6.example.com (2001:db8::2): icmp_seq=1 ttl=120 time=20.1 ms
+
+--- ipv6.example.com ping statistics ---
+1 packets transmitted, 1 received, 0% packet loss, time 0ms
+rtt min/avg/max/mdev = 20.100/20.100/20.100/0.000 ms
+`,
+			want: &PingResult{
+				Target: "ipv6.example.com", Address: "2001:db8::2",
+				Sent: 1, Received: 1,
+				MinMS: 20.1, AvgMS: 20.1, MaxMS: 20.1,
+				Replies:   []PingReply{{Seq: 1, TTL: 120, TimeMS: 20.1}},
+				Reachable: true, OSHint: "Windows",
+			},
+		},
+		{
+			name:   "busybox",
+			target: "10.0.0.1",
+			output: `PING 10.0.0.1 (10.0.0.1): 56 data bytes
+64 bytes from 10.0.0.1: seq=0 ttl=64 time=0.090 ms
+64 bytes from 10.0.0.1: seq=1 ttl=64 time=0.110 ms
+
+--- 10.0.0.1 ping statistics ---
+2 packets transmitted, 2 packets received, 0% packet loss
+round-trip min/avg/max = 0.090/0.100/0.110 ms
+`,
+			want: &PingResult{
+				Target: "10.0.0.1", Address: "10.0.0.1",
+				Sent: 2, Received: 2,
+				MinMS: 0.09, AvgMS: 0.1, MaxMS: 0.11,
+				Replies:   []PingReply{{Seq: 0, TTL: 64, TimeMS: 0.09}, {Seq: 1, TTL: 64, TimeMS: 0.11}},
+				Reachable: true, OSHint: "Linux/Unix",
+			},
+		},
+		{
+			name:   "unreachable",
+			target: "10.0.0.9",
+			output: `PING 10.0.0.9 (10.0.0.9) 56(84) bytes of data.
+
+--- 10.0.0.9 ping statistics ---
+2 packets transmitted, 0 received, 100% packet loss, time 1030ms
+`,
+			want: &PingResult{
+				Target: "10.0.0.9", Address: "10.0.0.9",
+				Sent: 2, LossPercent: 100,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParsePingOutput(tt.target, tt.output)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package parsers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Progress is how far a running tool got, as reported in its output
+type Progress struct {
+	Current float64
+	// Total is zero when the tool does not know how much work is left
+	Total   float64
+	Message string
+}
+
+// ProgressParser extracts the progress from a line of tool output. It
+// returns false for lines that do not report progress.
+type ProgressParser func(line string) (Progress, bool)
+
+// ProgressParserFor returns the progress parser of the named tool, or nil
+// if the tool does not report its progress
+func ProgressParserFor(tool string) ProgressParser {
+	switch tool {
+	case "nmap_scan":
+		return parseNmapProgress
+	case "hydra_attack":
+		return parseHydraProgress
+	case "gobuster_scan":
+		return parseGobusterProgress
+	}
+	return nil
+}
+
+// nmapProgressPattern matches the timing lines printed with --stats-every,
+// such as "SYN Stealth Scan Timing: About 23.45% done; ETC: 12:34 (0:00:40 remaining)"
+var nmapProgressPattern = regexp.MustCompile(`^(.+?) Timing: About ([\d.]+)% done(?:; ETC: \S+ \((\S+) remaining\))?`)
+
+// parseNmapProgress reads the percentage of the current Nmap scan phase
+func parseNmapProgress(line string) (Progress, bool) {
+	match := nmapProgressPattern.FindStringSubmatch(strings.TrimSpace(StripANSI(line)))
+	if match == nil {
+		return Progress{}, false
+	}
+	percent := parseFloat(match[2])
+	message := fmt.Sprintf("%s %s%% done", match[1], match[2])
+	if match[3] != "" {
+		message += ", " + match[3] + " remaining"
+	}
+	return Progress{Current: percent, Total: 100, Message: message}, true
+}
+
+// hydraProgressPattern matches status lines such as
+// "[STATUS] 1024.00 tries/min, 1024 tries in 00:01h, 13320 to do in 00:14h, 16 active"
+var hydraProgressPattern = regexp.MustCompile(`^\[STATUS\] ([\d.]+) tries/min, (\d+) tries in \S+, (\d+) to do in ([^,\s]+)`)
+
+// parseHydraProgress reads the attempts made and left by Hydra
+func parseHydraProgress(line string) (Progress, bool) {
+	match := hydraProgressPattern.FindStringSubmatch(strings.TrimSpace(StripANSI(line)))
+	if match == nil {
+		return Progress{}, false
+	}
+	tries := parseFloat(match[2])
+	todo := parseFloat(match[3])
+	return Progress{
+		Current: tries,
+		Total:   tries + todo,
+		Message: fmt.Sprintf("%.1f attempts/sec, %s of %.0f attempts done, %s left", parseFloat(match[1])/60, match[2], tries+todo, match[4]),
+	}, true
+}
+
+// gobusterProgressPattern matches progress lines such as
+// "Progress: 1234 / 4614 (26.74%)"
+var gobusterProgressPattern = regexp.MustCompile(`Progress: (\d+) / (\d+)`)
+
+// parseGobusterProgress reads the requests made and left by Gobuster
+func parseGobusterProgress(line string) (Progress, bool) {
+	match := gobusterProgressPattern.FindStringSubmatch(StripANSI(line))
+	if match == nil {
+		return Progress{}, false
+	}
+	return Progress{
+		Current: parseFloat(match[1]),
+		Total:   parseFloat(match[2]),
+		Message: fmt.Sprintf("%s of %s requests done", match[1], match[2]),
+	}, true
+}
@@ -0,0 +1,238 @@
+package parsers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SqlmapReport is the state of a sqlmap output directory
+type SqlmapReport struct {
+	Targets []SqlmapTarget `json:"targets"`
+}
+
+// SqlmapTarget is what sqlmap found out about one target host
+type SqlmapTarget struct {
+	Host            string              `json:"host"`
+	URL             string              `json:"url,omitempty"`
+	Method          string              `json:"method,omitempty"`
+	InjectionPoints []SqlmapInjection   `json:"injection_points"`
+	DBMS            string              `json:"dbms,omitempty"`
+	OS              string              `json:"os,omitempty"`
+	WebTechnology   string              `json:"web_technology,omitempty"`
+	Databases       []string            `json:"databases,omitempty"`
+	Tables          map[string][]string `json:"tables,omitempty"`
+	DumpedTables    []SqlmapDump        `json:"dumped_tables,omitempty"`
+}
+
+// SqlmapInjection is an injectable parameter
+type SqlmapInjection struct {
+	Parameter  string            `json:"parameter"`
+	Place      string            `json:"place"`
+	Techniques []SqlmapTechnique `json:"techniques"`
+}
+
+// SqlmapTechnique is a technique that works against an injectable parameter
+type SqlmapTechnique struct {
+	Type    string `json:"type"`
+	Title   string `json:"title,omitempty"`
+	Payload string `json:"payload,omitempty"`
+}
+
+// SqlmapDump is a table dumped by sqlmap
+type SqlmapDump struct {
+	Database string   `json:"database"`
+	Table    string   `json:"table"`
+	Columns  []string `json:"columns,omitempty"`
+	Rows     int      `json:"rows"`
+	File     string   `json:"file"`
+}
+
+var (
+	sqlmapParameterPattern = regexp.MustCompile(`^Parameter: (.+) \((.+)\)$`)
+	sqlmapTargetPattern    = regexp.MustCompile(`^(\S+) \((\w+)\)`)
+	sqlmapCountPattern     = regexp.MustCompile(`^\[\d+ tables?\]$`)
+	sqlmapTableRowPattern  = regexp.MustCompile(`^\|\s*(.+?)\s*\|$`)
+)
+
+// ParseSqlmapOutputDir parses every target directory below a sqlmap
+// --output-dir: the target file, the session log and dumped tables
+func ParseSqlmapOutputDir(dir string) (*SqlmapReport, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	report := &SqlmapReport{Targets: []SqlmapTarget{}}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		hostDir := filepath.Join(dir, entry.Name())
+		target := SqlmapTarget{Host: entry.Name(), InjectionPoints: []SqlmapInjection{}}
+		if data, err := os.ReadFile(filepath.Join(hostDir, "target.txt")); err == nil {
+			if m := sqlmapTargetPattern.FindStringSubmatch(strings.TrimSpace(string(data))); m != nil {
+				target.URL, target.Method = m[1], m[2]
+			}
+		}
+		if data, err := os.ReadFile(filepath.Join(hostDir, "log")); err == nil {
+			target.parseLog(string(data))
+		}
+		target.DumpedTables = parseSqlmapDumps(filepath.Join(hostDir, "dump"))
+		report.Targets = append(report.Targets, target)
+	}
+	return report, nil
+}
+
+// Summary returns a one line description of the report
+func (r *SqlmapReport) Summary() string {
+	injectable, dumped := 0, 0
+	dbms := ""
+	for _, t := range r.Targets {
+		injectable += len(t.InjectionPoints)
+		dumped += len(t.DumpedTables)
+		if dbms == "" {
+			dbms = t.DBMS
+		}
+	}
+	if injectable == 0 {
+		return "SQLmap found no injectable parameters"
+	}
+	summary := fmt.Sprintf("SQLmap found %d injectable parameters", injectable)
+	if dbms != "" {
+		summary += ", back-end DBMS: " + dbms
+	}
+	if dumped > 0 {
+		summary += fmt.Sprintf(", %d tables dumped", dumped)
+	}
+	return summary
+}
+
+// parseLog reads the findings from sqlmap's session log. The log grows
+// with every run against the target, so findings are merged.
+func (t *SqlmapTarget) parseLog(log string) {
+	lines := strings.Split(strings.ReplaceAll(log, "\r\n", "\n"), "\n")
+	var injection *SqlmapInjection
+	var technique *SqlmapTechnique
+	database := ""
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case sqlmapParameterPattern.MatchString(line):
+			m := sqlmapParameterPattern.FindStringSubmatch(line)
+			injection = t.injection(m[1], m[2])
+			technique = nil
+		case injection != nil && strings.HasPrefix(line, "Type: "):
+			technique = injection.technique(strings.TrimPrefix(line, "Type: "))
+		case technique != nil && strings.HasPrefix(line, "Title: "):
+			technique.Title = strings.TrimPrefix(line, "Title: ")
+		case technique != nil && strings.HasPrefix(line, "Payload: "):
+			technique.Payload = strings.TrimPrefix(line, "Payload: ")
+		case line == "---":
+			// Start or end of the injection point block
+			injection, technique = nil, nil
+		case strings.HasPrefix(line, "back-end DBMS: "):
+			t.DBMS = strings.TrimPrefix(line, "back-end DBMS: ")
+		case strings.HasPrefix(line, "web server operating system: "):
+			t.OS = strings.TrimPrefix(line, "web server operating system: ")
+		case strings.HasPrefix(line, "web application technology: "):
+			t.WebTechnology = strings.TrimPrefix(line, "web application technology: ")
+		case strings.HasPrefix(line, "available databases"):
+			for i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), "[*] ") {
+				i++
+				t.Databases = appendUnique(t.Databases, strings.TrimPrefix(strings.TrimSpace(lines[i]), "[*] "))
+			}
+		case strings.HasPrefix(line, "Database: "):
+			database = strings.TrimPrefix(line, "Database: ")
+		case database != "" && sqlmapCountPattern.MatchString(line):
+			// A table listing: "+---+", "| name |" rows, "+---+"
+			if t.Tables == nil {
+				t.Tables = map[string][]string{}
+			}
+			for i+1 < len(lines) {
+				next := strings.TrimSpace(lines[i+1])
+				if strings.HasPrefix(next, "+") {
+					i++
+					continue
+				}
+				m := sqlmapTableRowPattern.FindStringSubmatch(next)
+				if m == nil {
+					break
+				}
+				i++
+				t.Tables[database] = appendUnique(t.Tables[database], m[1])
+			}
+			database = ""
+		}
+	}
+}
+
+// injection returns the injection point of parameter, adding it if needed
+func (t *SqlmapTarget) injection(parameter, place string) *SqlmapInjection {
+	for i := range t.InjectionPoints {
+		if t.InjectionPoints[i].Parameter == parameter && t.InjectionPoints[i].Place == place {
+			return &t.InjectionPoints[i]
+		}
+	}
+	t.InjectionPoints = append(t.InjectionPoints, SqlmapInjection{Parameter: parameter, Place: place})
+	return &t.InjectionPoints[len(t.InjectionPoints)-1]
+}
+
+// technique returns the technique of the given type, adding it if needed
+func (inj *SqlmapInjection) technique(techniqueType string) *SqlmapTechnique {
+	for i := range inj.Techniques {
+		if inj.Techniques[i].Type == techniqueType {
+			return &inj.Techniques[i]
+		}
+	}
+	inj.Techniques = append(inj.Techniques, SqlmapTechnique{Type: techniqueType})
+	return &inj.Techniques[len(inj.Techniques)-1]
+}
+
+// parseSqlmapDumps lists the tables dumped as dump/<database>/<table>.csv
+func parseSqlmapDumps(dir string) []SqlmapDump {
+	files, _ := filepath.Glob(filepath.Join(dir, "*", "*.csv"))
+	sort.Strings(files)
+	var dumps []SqlmapDump
+	for _, file := range files {
+		dump := SqlmapDump{
+			Database: filepath.Base(filepath.Dir(file)),
+			Table:    strings.TrimSuffix(filepath.Base(file), ".csv"),
+			File:     file,
+		}
+		if f, err := os.Open(file); err == nil {
+			r := csv.NewReader(f)
+			r.FieldsPerRecord = -1
+			r.LazyQuotes = true
+			dump.Columns, _ = r.Read()
+			for {
+				if _, err := r.Read(); err != nil {
+					var parseErr *csv.ParseError
+					if errors.As(err, &parseErr) {
+						continue
+					}
+					break
+				}
+				dump.Rows++
+			}
+			f.Close()
+		}
+		dumps = append(dumps, dump)
+	}
+	return dumps
+}
+
+// appendUnique appends s to list unless it is already in it
+func appendUnique(list []string, s string) []string {
+	for _, item := range list {
+		if item == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,79 @@
+package parsers
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestParseSqlmapOutputDir parses a sqlmap output directory with a target
+// whose session log grew over two runs, one resuming the injection point
+// of the other, and a target where nothing was found
+func TestParseSqlmapOutputDir(t *testing.T) {
+	dir := filepath.Join("testdata", "sqlmap-1.8")
+	want := &SqlmapReport{Targets: []SqlmapTarget{
+		{
+			Host:   "10.0.0.5",
+			URL:    "http://10.0.0.5:80/dvwa/vulnerabilities/sqli/?id=1&Submit=Submit",
+			Method: "GET",
+			InjectionPoints: []SqlmapInjection{{
+				Parameter: "id",
+				Place:     "GET",
+				Techniques: []SqlmapTechnique{
+					{
+						Type:    "boolean-based blind",
+						Title:   "OR boolean-based blind - WHERE or HAVING clause (NOT - MySQL comment)",
+						Payload: "id=1' OR NOT 8213=8213#&Submit=Submit",
+					},
+					{
+						Type:    "error-based",
+						Title:   "MySQL >= 5.0 AND error-based - WHERE, HAVING, ORDER BY or GROUP BY clause (FLOOR)",
+						Payload: "id=1' AND (SELECT 4523 FROM(SELECT COUNT(*),CONCAT(0x7176787671,(SELECT (ELT(4523=4523,1))),0x716b6b7671,FLOOR(RAND(0)*2))x FROM INFORMATION_SCHEMA.PLUGINS GROUP BY x)a)-- PwDd&Submit=Submit",
+					},
+					{
+						Type:    "time-based blind",
+						Title:   "MySQL >= 5.0.12 AND time-based blind (query SLEEP)",
+						Payload: "id=1' AND (SELECT 6354 FROM (SELECT(SLEEP(5)))qWcb)-- eFjK&Submit=Submit",
+					},
+					{
+						Type:    "UNION query",
+						Title:   "MySQL UNION query (NULL) - 2 columns",
+						Payload: "id=1' UNION ALL SELECT CONCAT(0x7176787671,0x4a6f6a4c7a4b6e6a5a6d,0x716b6b7671),NULL#&Submit=Submit",
+					},
+				},
+			}},
+			DBMS:          "MySQL >= 4.1",
+			OS:            "Linux Ubuntu 8.04 (Hardy Heron)",
+			WebTechnology: "PHP 5.2.4, Apache 2.2.8",
+			Databases:     []string{"dvwa", "information_schema", "metasploit", "mysql"},
+			Tables:        map[string][]string{"dvwa": {"guestbook", "users"}},
+			DumpedTables: []SqlmapDump{{
+				Database: "dvwa",
+				Table:    "users",
+				Columns:  []string{"user_id", "user", "avatar", "password", "last_name", "first_name"},
+				Rows:     5,
+				File:     filepath.Join(dir, "10.0.0.5", "dump", "dvwa", "users.csv"),
+			}},
+		},
+		{
+			Host:            "10.0.0.7",
+			URL:             "http://10.0.0.7:80/item.php?id=2",
+			Method:          "GET",
+			InjectionPoints: []SqlmapInjection{},
+		},
+	}}
+
+	got, err := ParseSqlmapOutputDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.MarshalIndent(got, "", "  ")
+		wantJSON, _ := json.MarshalIndent(want, "", "  ")
+		t.Errorf("got\n%s\nwant\n%s", gotJSON, wantJSON)
+	}
+	if summary, want := got.Summary(), "SQLmap found 1 injectable parameters, back-end DBMS: MySQL >= 4.1, 1 tables dumped"; summary != want {
+		t.Errorf("summary %q, want %q", summary, want)
+	}
+}
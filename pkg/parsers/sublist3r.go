@@ -0,0 +1,93 @@
+package parsers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// "www.example.com - Found open ports: 80, 443"
+	sublist3rPortsPattern = regexp.MustCompile(`^(\S+)\s+-\s+Found open ports:\s*(.*)$`)
+	hostnamePattern       = regexp.MustCompile(`^[A-Za-z0-9_*]([A-Za-z0-9_.-]*[A-Za-z0-9])?$`)
+)
+
+// ParseSublist3rOutput parses the subdomains of domain listed by Sublist3r,
+// without colors, banner and progress messages and without duplicates.
+// Sublist3r's "<br>" separators are split into separate names.
+func ParseSublist3rOutput(domain, output string) []Subdomain {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	subdomains := []Subdomain{}
+	index := map[string]int{}
+
+	add := func(name string) *Subdomain {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if name != domain && !strings.HasSuffix(name, "."+domain) || !hostnamePattern.MatchString(name) {
+			return nil
+		}
+		i, ok := index[name]
+		if !ok {
+			subdomains = append(subdomains, Subdomain{Subdomain: name})
+			i = len(subdomains) - 1
+			index[name] = i
+		}
+		return &subdomains[i]
+	}
+
+	for _, line := range cleanLines(output) {
+		if strings.HasPrefix(line, "[") {
+			continue
+		}
+		if m := sublist3rPortsPattern.FindStringSubmatch(line); m != nil {
+			if sub := add(m[1]); sub != nil {
+				for _, port := range splitList(m[2]) {
+					if n, err := strconv.Atoi(port); err == nil {
+						sub.Ports = append(sub.Ports, n)
+					}
+				}
+			}
+			continue
+		}
+		for _, name := range strings.Split(line, "<BR>") {
+			for _, name := range strings.Split(name, "<br>") {
+				add(strings.TrimSpace(name))
+			}
+		}
+	}
+	return subdomains
+}
+
+// SubdomainReport is the result of a subdomain enumeration
+type SubdomainReport struct {
+	Domain     string      `json:"domain"`
+	Subdomains []Subdomain `json:"subdomains"`
+	// Resolved is set when the subdomains were resolved to IPs and CNAMEs
+	Resolved bool `json:"resolved"`
+	// WildcardDNS is set when names that do not exist resolve too
+	WildcardDNS bool     `json:"wildcard_dns"`
+	WildcardIPs []string `json:"wildcard_ips,omitempty"`
+	// TargetList is a file listing one subdomain per line, for use with
+	// other tools, e.g. nmap -iL
+	TargetList string `json:"target_list,omitempty"`
+}
+
+// Summary returns a one line description of the report
+func (r *SubdomainReport) Summary() string {
+	summary := fmt.Sprintf("Sublist3r found %d subdomains of %s", len(r.Subdomains), r.Domain)
+	if r.Resolved {
+		resolved, wildcard := 0, 0
+		for _, sub := range r.Subdomains {
+			if sub.Wildcard {
+				wildcard++
+			} else if len(sub.IPs) > 0 {
+				resolved++
+			}
+		}
+		summary += fmt.Sprintf(", %d resolved", resolved)
+		if r.WildcardDNS {
+			summary += fmt.Sprintf(", %d only through wildcard DNS", wildcard)
+		}
+	}
+	return summary
+}
@@ -0,0 +1,58 @@
+package parsers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestParseSublist3rOutput parses colored Sublist3r output with its banner,
+// progress and error messages, names joined by "<BR>", and the open ports
+// found with -p
+func TestParseSublist3rOutput(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "sublist3r-1.1.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Subdomain{
+		{Subdomain: "www.example.com", Ports: []int{80, 443}},
+		{Subdomain: "api.example.com", Ports: []int{443}},
+		{Subdomain: "dev.example.com"},
+		{Subdomain: "staging.example.com"},
+		{Subdomain: "mail.example.com"},
+		{Subdomain: "example.com"},
+	}
+	got := ParseSublist3rOutput("Example.com", string(data))
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.MarshalIndent(got, "", "  ")
+		wantJSON, _ := json.MarshalIndent(want, "", "  ")
+		t.Errorf("got\n%s\nwant\n%s", gotJSON, wantJSON)
+	}
+
+	// Names of other domains are not subdomains
+	if got := ParseSublist3rOutput("ample.com", string(data)); len(got) != 0 {
+		t.Errorf("got subdomains of ample.com: %+v", got)
+	}
+}
+
+// TestSubdomainReportSummary counts resolved and wildcard subdomains once
+// they were resolved
+func TestSubdomainReportSummary(t *testing.T) {
+	report := &SubdomainReport{
+		Domain: "example.com",
+		Subdomains: []Subdomain{
+			{Subdomain: "www.example.com", IPs: []string{"93.184.215.14"}},
+			{Subdomain: "old.example.com"},
+			{Subdomain: "x.example.com", IPs: []string{"10.9.9.9"}, Wildcard: true},
+		},
+	}
+	if got, want := report.Summary(), "Sublist3r found 3 subdomains of example.com"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	report.Resolved, report.WildcardDNS = true, true
+	if got, want := report.Summary(), "Sublist3r found 3 subdomains of example.com, 1 resolved, 1 only through wildcard DNS"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,31 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ansiPattern matches terminal color and cursor control sequences
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// StripANSI removes terminal escape sequences from s
+func StripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+// cleanLines splits tool output into lines without escape sequences.
+// Progress output redrawn with carriage returns only keeps its final state.
+func cleanLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(StripANSI(output), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if i := strings.LastIndex(line, "\r"); i >= 0 {
+			line = line[i+1:]
+		}
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,357 @@
+package parsers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WPScanReport is the parsed JSON output of WPScan
+type WPScanReport struct {
+	TargetURL           string            `json:"target_url,omitempty"`
+	EffectiveURL        string            `json:"effective_url,omitempty"`
+	WordPress           *WPScanVersion    `json:"wordpress,omitempty"`
+	MainTheme           *WPScanComponent  `json:"main_theme,omitempty"`
+	Plugins             []WPScanComponent `json:"plugins"`
+	Themes              []WPScanComponent `json:"themes"`
+	Users               []WPScanUser      `json:"users"`
+	InterestingFindings []WPScanFinding   `json:"interesting_findings"`
+	// Counts holds the number of vulnerabilities per severity
+	Counts map[string]int `json:"vulnerability_counts"`
+	// Aborted is the reason WPScan stopped early, if it did
+	Aborted string `json:"aborted,omitempty"`
+}
+
+// WPScanVersion is the detected WordPress version
+type WPScanVersion struct {
+	Number          string                `json:"number"`
+	Status          string                `json:"status,omitempty"`
+	ReleaseDate     string                `json:"release_date,omitempty"`
+	Vulnerabilities []WPScanVulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// WPScanComponent is a detected plugin or theme
+type WPScanComponent struct {
+	Slug            string                `json:"slug"`
+	Version         string                `json:"version,omitempty"`
+	LatestVersion   string                `json:"latest_version,omitempty"`
+	Outdated        bool                  `json:"outdated"`
+	Location        string                `json:"location,omitempty"`
+	Vulnerabilities []WPScanVulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// WPScanUser is an enumerated WordPress user
+type WPScanUser struct {
+	Username string `json:"username"`
+	ID       int    `json:"id,omitempty"`
+}
+
+// WPScanFinding is an interesting finding such as an exposed file or header
+type WPScanFinding struct {
+	URL         string   `json:"url,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Description string   `json:"description"`
+	Entries     []string `json:"entries,omitempty"`
+}
+
+// WPScanVulnerability is a known vulnerability of WordPress or a component.
+// Severity is derived from the CVSS score and is "unknown" without one.
+type WPScanVulnerability struct {
+	Title      string   `json:"title"`
+	Type       string   `json:"type,omitempty"`
+	FixedIn    string   `json:"fixed_in,omitempty"`
+	CVEs       []string `json:"cves,omitempty"`
+	References []string `json:"references,omitempty"`
+	CVSSScore  float64  `json:"cvss_score,omitempty"`
+	Severity   string   `json:"severity"`
+}
+
+// wpscanJSON mirrors the parts of WPScan's JSON output that are kept
+type wpscanJSON struct {
+	TargetURL           string `json:"target_url"`
+	EffectiveURL        string `json:"effective_url"`
+	ScanAborted         string `json:"scan_aborted"`
+	InterestingFindings []struct {
+		URL     string   `json:"url"`
+		ToS     string   `json:"to_s"`
+		Type    string   `json:"type"`
+		Entries []string `json:"interesting_entries"`
+	} `json:"interesting_findings"`
+	Version *struct {
+		Number          string           `json:"number"`
+		Status          string           `json:"status"`
+		ReleaseDate     string           `json:"release_date"`
+		Vulnerabilities []wpscanJSONVuln `json:"vulnerabilities"`
+	} `json:"version"`
+	MainTheme *wpscanJSONComponent           `json:"main_theme"`
+	Plugins   map[string]wpscanJSONComponent `json:"plugins"`
+	Themes    map[string]wpscanJSONComponent `json:"themes"`
+	Users     map[string]struct {
+		ID int `json:"id"`
+	} `json:"users"`
+}
+
+type wpscanJSONComponent struct {
+	Slug          string `json:"slug"`
+	Location      string `json:"location"`
+	LatestVersion string `json:"latest_version"`
+	Outdated      bool   `json:"outdated"`
+	Version       *struct {
+		Number string `json:"number"`
+	} `json:"version"`
+	Vulnerabilities []wpscanJSONVuln `json:"vulnerabilities"`
+}
+
+type wpscanJSONVuln struct {
+	Title      string `json:"title"`
+	VulnType   string `json:"vuln_type"`
+	FixedIn    string `json:"fixed_in"`
+	References struct {
+		CVE []string `json:"cve"`
+		URL []string `json:"url"`
+	} `json:"references"`
+	CVSS *struct {
+		Score looseFloat `json:"score"`
+	} `json:"cvss"`
+}
+
+// looseFloat accepts a JSON number or a string holding one, as WPScan
+// reports CVSS scores either way depending on version. Anything else is 0.
+type looseFloat float64
+
+// UnmarshalJSON implements json.Unmarshaler
+func (f *looseFloat) UnmarshalJSON(data []byte) error {
+	n, _ := strconv.ParseFloat(strings.Trim(string(data), `"`), 64)
+	*f = looseFloat(n)
+	return nil
+}
+
+// ParseWPScanJSONFile parses the WPScan JSON report at path
+func ParseWPScanJSONFile(path string) (*WPScanReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseWPScanJSON(data)
+}
+
+// ParseWPScanJSON parses WPScan's JSON output
+func ParseWPScanJSON(data []byte) (*WPScanReport, error) {
+	var raw wpscanJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse wpscan JSON: %w", err)
+	}
+
+	report := &WPScanReport{
+		TargetURL:           raw.TargetURL,
+		EffectiveURL:        raw.EffectiveURL,
+		Plugins:             []WPScanComponent{},
+		Themes:              []WPScanComponent{},
+		Users:               []WPScanUser{},
+		InterestingFindings: []WPScanFinding{},
+		Aborted:             raw.ScanAborted,
+	}
+	for _, f := range raw.InterestingFindings {
+		report.InterestingFindings = append(report.InterestingFindings, WPScanFinding{
+			URL:         f.URL,
+			Type:        f.Type,
+			Description: f.ToS,
+			Entries:     f.Entries,
+		})
+	}
+	if raw.Version != nil {
+		report.WordPress = &WPScanVersion{
+			Number:          raw.Version.Number,
+			Status:          raw.Version.Status,
+			ReleaseDate:     raw.Version.ReleaseDate,
+			Vulnerabilities: convertWPScanVulns(raw.Version.Vulnerabilities),
+		}
+	}
+	if raw.MainTheme != nil {
+		theme := convertWPScanComponent(*raw.MainTheme)
+		report.MainTheme = &theme
+	}
+	report.Plugins = convertWPScanComponents(raw.Plugins)
+	report.Themes = convertWPScanComponents(raw.Themes)
+
+	for username, user := range raw.Users {
+		report.Users = append(report.Users, WPScanUser{Username: username, ID: user.ID})
+	}
+	sort.Slice(report.Users, func(i, j int) bool {
+		return report.Users[i].Username < report.Users[j].Username
+	})
+
+	report.count()
+	return report, nil
+}
+
+// FilterSeverity drops the vulnerabilities less severe than minSeverity.
+// Vulnerabilities of unknown severity are kept, as WPScan only knows the
+// CVSS score of some of them.
+func (r *WPScanReport) FilterSeverity(minSeverity string) error {
+	if minSeverity == "" {
+		return nil
+	}
+	if err := ValidateSeverity(minSeverity); err != nil {
+		return err
+	}
+	min := severityRank(minSeverity)
+	filter := func(vulns []WPScanVulnerability) []WPScanVulnerability {
+		var kept []WPScanVulnerability
+		for _, v := range vulns {
+			if v.Severity == "unknown" || severityRank(v.Severity) <= min {
+				kept = append(kept, v)
+			}
+		}
+		return kept
+	}
+	if r.WordPress != nil {
+		r.WordPress.Vulnerabilities = filter(r.WordPress.Vulnerabilities)
+	}
+	if r.MainTheme != nil {
+		r.MainTheme.Vulnerabilities = filter(r.MainTheme.Vulnerabilities)
+	}
+	for i := range r.Plugins {
+		r.Plugins[i].Vulnerabilities = filter(r.Plugins[i].Vulnerabilities)
+	}
+	for i := range r.Themes {
+		r.Themes[i].Vulnerabilities = filter(r.Themes[i].Vulnerabilities)
+	}
+	r.count()
+	return nil
+}
+
+// Summary returns a one line description of the report
+func (r *WPScanReport) Summary() string {
+	version := "unknown version"
+	if r.WordPress != nil && r.WordPress.Number != "" {
+		version = r.WordPress.Number
+	}
+	total := 0
+	var parts []string
+	for _, severity := range Severities {
+		if count := r.Counts[severity]; count > 0 {
+			total += count
+			parts = append(parts, fmt.Sprintf("%d %s", count, severity))
+		}
+	}
+	summary := fmt.Sprintf("WPScan found WordPress %s, %d plugins, %d themes, %d users, %d vulnerabilities",
+		version, len(r.Plugins), len(r.Themes), len(r.Users), total)
+	if total > 0 {
+		summary += " (" + strings.Join(parts, ", ") + ")"
+	}
+	return summary
+}
+
+// count recomputes the number of vulnerabilities per severity
+func (r *WPScanReport) count() {
+	r.Counts = map[string]int{}
+	add := func(vulns []WPScanVulnerability) {
+		for _, v := range vulns {
+			r.Counts[v.Severity]++
+		}
+	}
+	if r.WordPress != nil {
+		add(r.WordPress.Vulnerabilities)
+	}
+	if r.MainTheme != nil {
+		add(r.MainTheme.Vulnerabilities)
+	}
+	for _, c := range r.Plugins {
+		add(c.Vulnerabilities)
+	}
+	for _, c := range r.Themes {
+		add(c.Vulnerabilities)
+	}
+}
+
+// convertWPScanComponents converts plugins or themes, sorted by slug
+func convertWPScanComponents(raw map[string]wpscanJSONComponent) []WPScanComponent {
+	list := []WPScanComponent{}
+	for slug, c := range raw {
+		if c.Slug == "" {
+			c.Slug = slug
+		}
+		list = append(list, convertWPScanComponent(c))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Slug < list[j].Slug })
+	return list
+}
+
+func convertWPScanComponent(c wpscanJSONComponent) WPScanComponent {
+	component := WPScanComponent{
+		Slug:            c.Slug,
+		LatestVersion:   c.LatestVersion,
+		Outdated:        c.Outdated,
+		Location:        c.Location,
+		Vulnerabilities: convertWPScanVulns(c.Vulnerabilities),
+	}
+	if c.Version != nil {
+		component.Version = c.Version.Number
+	}
+	return component
+}
+
+func convertWPScanVulns(raw []wpscanJSONVuln) []WPScanVulnerability {
+	var vulns []WPScanVulnerability
+	for _, v := range raw {
+		vuln := WPScanVulnerability{
+			Title:      v.Title,
+			Type:       v.VulnType,
+			FixedIn:    v.FixedIn,
+			References: v.References.URL,
+			Severity:   "unknown",
+		}
+		for _, cve := range v.References.CVE {
+			if !strings.HasPrefix(strings.ToUpper(cve), "CVE-") {
+				cve = "CVE-" + cve
+			}
+			vuln.CVEs = append(vuln.CVEs, cve)
+		}
+		if v.CVSS != nil {
+			if score := float64(v.CVSS.Score); score > 0 {
+				vuln.CVSSScore = score
+				vuln.Severity = cvssSeverity(score)
+			}
+		}
+		vulns = append(vulns, vuln)
+	}
+	return vulns
+}
+
+// cvssSeverity maps a CVSS v3 score to its qualitative severity
+func cvssSeverity(score float64) string {
+	switch {
+	case score >= 9:
+		return "critical"
+	case score >= 7:
+		return "high"
+	case score >= 4:
+		return "medium"
+	case score > 0:
+		return "low"
+	default:
+		return "info"
+	}
+}
+
+// ValidateSeverity checks that severity can be used as a minimum severity
+func ValidateSeverity(severity string) error {
+	if severityRank(severity) < 0 || severity == "unknown" {
+		return fmt.Errorf("invalid severity: %s. Must be one of: critical, high, medium, low, info", severity)
+	}
+	return nil
+}
+
+// severityRank returns the position of severity in Severities, or -1
+func severityRank(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,139 @@
+package parsers
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestParseWPScanJSONFile parses a WPScan 3.8 report with vulnerable
+// WordPress core and plugins, CVSS scores as numbers and as strings, and
+// the report of a scan WPScan aborted
+func TestParseWPScanJSONFile(t *testing.T) {
+	tests := []struct {
+		file    string
+		want    *WPScanReport
+		summary string
+	}{
+		{
+			file: "wpscan-3.8.json",
+			want: &WPScanReport{
+				TargetURL:    "http://10.0.0.9/",
+				EffectiveURL: "http://10.0.0.9/",
+				WordPress: &WPScanVersion{
+					Number:      "5.8.1",
+					Status:      "insecure",
+					ReleaseDate: "2021-09-09",
+					Vulnerabilities: []WPScanVulnerability{
+						{
+							Title:      "WordPress < 5.8.3 - SQL Injection via WP_Query",
+							FixedIn:    "5.8.3",
+							CVEs:       []string{"CVE-2022-21661"},
+							References: []string{"https://github.com/WordPress/wordpress-develop/security/advisories/GHSA-6676-cqfm-gw84"},
+							CVSSScore:  7.5,
+							Severity:   "high",
+						},
+						{
+							Title:      "WordPress < 6.0.3 - Email Address Disclosure via wp-mail.php",
+							FixedIn:    "5.8.5",
+							References: []string{"https://wordpress.org/news/2022/10/wordpress-6-0-3-security-release/"},
+							Severity:   "unknown",
+						},
+					},
+				},
+				MainTheme: &WPScanComponent{
+					Slug:          "twentytwentyone",
+					Version:       "1.4",
+					LatestVersion: "2.3",
+					Outdated:      true,
+					Location:      "http://10.0.0.9/wp-content/themes/twentytwentyone/",
+				},
+				Plugins: []WPScanComponent{
+					{
+						Slug:          "akismet",
+						LatestVersion: "5.3.3",
+						Location:      "http://10.0.0.9/wp-content/plugins/akismet/",
+					},
+					{
+						Slug:          "wp-file-manager",
+						Version:       "6.0",
+						LatestVersion: "7.2.9",
+						Outdated:      true,
+						Location:      "http://10.0.0.9/wp-content/plugins/wp-file-manager/",
+						Vulnerabilities: []WPScanVulnerability{{
+							Title:      "File Manager 6.0-6.9 - Unauthenticated Arbitrary File Upload leading to RCE",
+							Type:       "RCE",
+							FixedIn:    "6.9",
+							CVEs:       []string{"CVE-2020-25213"},
+							References: []string{"https://www.wordfence.com/blog/2020/09/700000-wordpress-users-affected-by-zero-day-vulnerability-in-file-manager-plugin/"},
+							CVSSScore:  10,
+							Severity:   "critical",
+						}},
+					},
+				},
+				Themes: []WPScanComponent{},
+				Users:  []WPScanUser{{Username: "admin", ID: 1}, {Username: "editor", ID: 2}},
+				InterestingFindings: []WPScanFinding{
+					{URL: "http://10.0.0.9/", Type: "headers", Description: "Headers", Entries: []string{"Server: Apache/2.4.41 (Ubuntu)"}},
+					{URL: "http://10.0.0.9/xmlrpc.php", Type: "xmlrpc", Description: "XML-RPC seems to be enabled: http://10.0.0.9/xmlrpc.php", Entries: []string{}},
+					{URL: "http://10.0.0.9/readme.html", Type: "readme", Description: "WordPress readme found: http://10.0.0.9/readme.html", Entries: []string{}},
+				},
+				Counts: map[string]int{"critical": 1, "high": 1, "unknown": 1},
+			},
+			summary: "WPScan found WordPress 5.8.1, 2 plugins, 0 themes, 2 users, 3 vulnerabilities (1 critical, 1 high, 1 unknown)",
+		},
+		{
+			file: "wpscan-3.8-aborted.json",
+			want: &WPScanReport{
+				TargetURL:           "http://10.0.0.5/",
+				EffectiveURL:        "http://10.0.0.5/",
+				Plugins:             []WPScanComponent{},
+				Themes:              []WPScanComponent{},
+				Users:               []WPScanUser{},
+				InterestingFindings: []WPScanFinding{},
+				Counts:              map[string]int{},
+				Aborted:             "The remote website is up, but does not seem to be running WordPress.",
+			},
+			summary: "WPScan found WordPress unknown version, 0 plugins, 0 themes, 0 users, 0 vulnerabilities",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := ParseWPScanJSONFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.MarshalIndent(got, "", "  ")
+				wantJSON, _ := json.MarshalIndent(tt.want, "", "  ")
+				t.Errorf("got\n%s\nwant\n%s", gotJSON, wantJSON)
+			}
+			if summary := got.Summary(); summary != tt.summary {
+				t.Errorf("summary %q, want %q", summary, tt.summary)
+			}
+		})
+	}
+}
+
+// TestWPScanFilterSeverity keeps the vulnerabilities at or above the
+// minimum severity and those of unknown severity
+func TestWPScanFilterSeverity(t *testing.T) {
+	report, err := ParseWPScanJSONFile(filepath.Join("testdata", "wpscan-3.8.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.FilterSeverity("critical"); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"critical": 1, "unknown": 1}; !reflect.DeepEqual(report.Counts, want) {
+		t.Errorf("counts %v, want %v", report.Counts, want)
+	}
+	if n := len(report.WordPress.Vulnerabilities); n != 1 || report.WordPress.Vulnerabilities[0].Severity != "unknown" {
+		t.Errorf("WordPress vulnerabilities %+v, want only the one of unknown severity", report.WordPress.Vulnerabilities)
+	}
+	if err := report.FilterSeverity("severe"); err == nil {
+		t.Error("FilterSeverity accepted an invalid severity")
+	}
+}
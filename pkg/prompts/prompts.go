@@ -0,0 +1,165 @@
+package prompts
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/tools"
+)
+
+// Dir is the directory with the prompts of the team, in addition to the
+// built-in ones. Prompts in it replace built-in prompts of the same name.
+var Dir string
+
+// SetDir sets the directory prompts are loaded from
+func SetDir(dir string) {
+	Dir = dir
+}
+
+//go:embed builtin
+var builtin embed.FS
+
+// Argument is an argument of a prompt
+type Argument struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// Prompt is a playbook that expands into instructions for the model. It is
+// defined by a JSON file whose template is given inline or, for longer
+// texts, in a file next to it.
+type Prompt struct {
+	Name         string     `json:"name"`
+	Title        string     `json:"title,omitempty"`
+	Description  string     `json:"description"`
+	Arguments    []Argument `json:"arguments,omitempty"`
+	Template     string     `json:"template,omitempty"`
+	TemplateFile string     `json:"template_file,omitempty"`
+
+	tmpl *template.Template
+}
+
+// Render expands the prompt with the given arguments
+func (p *Prompt) Render(args map[string]string) (string, error) {
+	for _, arg := range p.Arguments {
+		if arg.Required && strings.TrimSpace(args[arg.Name]) == "" {
+			return "", fmt.Errorf("%s argument is required", arg.Name)
+		}
+	}
+	var b strings.Builder
+	if err := p.tmpl.Execute(&b, args); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s: %w", p.Name, err)
+	}
+	return b.String(), nil
+}
+
+// templateFuncs are the functions available to prompt templates. tool
+// returns the name of a tool and fails for tools the server does not have,
+// so that playbooks cannot refer to tools that do not exist.
+var templateFuncs = template.FuncMap{
+	"tool": func(name string) (string, error) {
+		if _, ok := tools.Lookup(name); !ok {
+			return "", fmt.Errorf("unknown tool: %s", name)
+		}
+		return name, nil
+	},
+}
+
+// All returns the built-in prompts and those in Dir, sorted by name
+func All() ([]*Prompt, error) {
+	byName := map[string]*Prompt{}
+	list, err := load(builtin, "builtin")
+	if err != nil {
+		return nil, err
+	}
+	if Dir != "" {
+		if _, err := os.Stat(Dir); err != nil {
+			return nil, err
+		}
+		custom, err := load(os.DirFS(Dir), ".")
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, custom...)
+	}
+	for _, p := range list {
+		byName[p.Name] = p
+	}
+
+	all := make([]*Prompt, 0, len(byName))
+	for _, p := range byName {
+		all = append(all, p)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all, nil
+}
+
+// load reads the prompts defined by the JSON files in dir of fsys.
+// Invalid prompts are logged and skipped, so that a mistake in one
+// playbook does not take the others down.
+func load(fsys fs.FS, dir string) ([]*Prompt, error) {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var list []*Prompt
+	for _, file := range files {
+		p, err := loadFile(fsys, file)
+		if err != nil {
+			log.Printf("Skipping prompt %s: %v", file, err)
+			continue
+		}
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+// loadFile reads and checks the prompt defined by file
+func loadFile(fsys fs.FS, file string) (*Prompt, error) {
+	data, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return nil, err
+	}
+	p := &Prompt{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(path.Base(file), ".json")
+	}
+	if p.TemplateFile != "" {
+		data, err := fs.ReadFile(fsys, path.Join(path.Dir(file), p.TemplateFile))
+		if err != nil {
+			return nil, err
+		}
+		p.Template = string(data)
+	}
+	if strings.TrimSpace(p.Template) == "" {
+		return nil, fmt.Errorf("template or template_file is required")
+	}
+	if p.tmpl, err = template.New(p.Name).Funcs(templateFuncs).Option("missingkey=zero").Parse(p.Template); err != nil {
+		return nil, err
+	}
+
+	// Render once with every argument set to catch references to unknown
+	// tools when the prompt is loaded rather than used
+	args := map[string]string{}
+	for _, arg := range p.Arguments {
+		args[arg.Name] = arg.Name
+	}
+	if _, err := p.Render(args); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,432 @@
+package runs
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/tools"
+)
+
+// Names of the files of a run in its artifact directory
+const (
+	MetadataFile = "run.json"
+	StdoutFile   = "stdout.log"
+	StderrFile   = "stderr.log"
+)
+
+// DefaultMaxRuns is the number of recorded runs kept by default
+const DefaultMaxRuns = 1000
+
+// Retention of the recorded runs: the oldest runs beyond MaxRuns and the
+// runs that finished more than MaxAge ago are removed together with their
+// artifacts whenever a run is recorded and when the runs are loaded. Zero
+// disables the limit.
+var (
+	MaxRuns = DefaultMaxRuns
+	MaxAge  time.Duration
+)
+
+// OrphanAge is how long the directory of a run without metadata is kept.
+// Such a directory belongs to a run that is still going, or to one that
+// failed or whose process exited before it was recorded. It is longer than
+// the longest tool timeout, so that runs of other processes sharing the
+// artifact directory are left alone.
+const OrphanAge = 24 * time.Hour
+
+// runIDPattern matches the directory names of runs, see executor.NewRunID
+var runIDPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// SetRetention sets how many runs are kept and for how long
+func SetRetention(maxRuns int, maxAge time.Duration) {
+	MaxRuns = maxRuns
+	MaxAge = maxAge
+}
+
+// Run is a finished tool run persisted in its artifact directory. Its
+// result does not hold the output, which is in the stdout and stderr
+// artifacts named by the result's stdout_file and stderr_file.
+type Run struct {
+	ID         string            `json:"id"`
+	Tool       string            `json:"tool"`
+	Target     string            `json:"target,omitempty"`
+	Arguments  json.RawMessage   `json:"arguments,omitempty"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Success    bool              `json:"success"`
+	Summary    string            `json:"summary,omitempty"`
+	Artifacts  []string          `json:"artifacts"`
+	Result     *tools.ToolResult `json:"result,omitempty"`
+}
+
+// targetKeys are the tool arguments naming the target of a run, by priority
+var targetKeys = []string{"target", "url", "domain"}
+
+// targetOf returns the target of a run from its arguments
+func targetOf(arguments json.RawMessage) string {
+	var args map[string]interface{}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return ""
+	}
+	for _, key := range targetKeys {
+		if s, ok := args[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	if list, ok := args["targets"].([]interface{}); ok && len(list) > 0 {
+		s, _ := list[0].(string)
+		return s
+	}
+	return ""
+}
+
+// Redacted replaces the values of secret arguments in run metadata
+const Redacted = "[REDACTED]"
+
+var (
+	// secretKeyPattern matches the names of arguments holding secrets, such
+	// as hydra's password or a metasploit module's SMBPass, but not those
+	// naming a file of them
+	secretKeyPattern = regexp.MustCompile(`(?i)pass|secret|token|cookie|cred|api_?key`)
+
+	// secretKeys are arguments that hold secrets although their names do
+	// not say so: shell command lines and sqlmap's POST data, which is
+	// often a login form
+	secretKeys = []string{"command", "data"}
+
+	// secretOptionPattern matches long options with a secret value in extra
+	// command line arguments, such as --cookie=... or --api-token ...
+	secretOptionPattern = regexp.MustCompile(`(?i)(--[\w-]*(?:pass|secret|token|cookie|cred|auth|header)[\w-]*)(=|\s+)("[^"]*"|'[^']*'|\S+)`)
+)
+
+// redactArguments returns the tool arguments with the values of secret
+// arguments replaced by Redacted, as run metadata is kept on disk and
+// served as a resource
+func redactArguments(arguments json.RawMessage) json.RawMessage {
+	var args map[string]interface{}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return arguments
+	}
+	redactMap(args)
+	data, err := json.Marshal(args)
+	if err != nil {
+		return arguments
+	}
+	return data
+}
+
+// redactMap redacts the secret values of args in place, including those of
+// nested options
+func redactMap(args map[string]interface{}) {
+	for key, value := range args {
+		if nested, ok := value.(map[string]interface{}); ok {
+			redactMap(nested)
+			continue
+		}
+		if key == "additional_args" {
+			if s, ok := value.(string); ok {
+				args[key] = secretOptionPattern.ReplaceAllString(s, "${1}${2}"+Redacted)
+			}
+			continue
+		}
+		if value != nil && isSecretKey(key) {
+			args[key] = Redacted
+		}
+	}
+}
+
+// isSecretKey reports whether the argument key holds a secret
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	if strings.HasSuffix(key, "file") {
+		return false
+	}
+	return slices.Contains(secretKeys, key) || secretKeyPattern.MatchString(key)
+}
+
+// Store keeps the finished tool runs and persists them in the artifact
+// directory, so that their output can be read after a restart
+type Store struct {
+	mu        sync.Mutex
+	runs      map[string]*Run
+	listeners []func(*Run)
+	removed   []func(*Run)
+}
+
+// DefaultStore is the run store shared by the handlers and jobs
+var DefaultStore = NewStore()
+
+// NewStore creates a new, empty Store
+func NewStore() *Store {
+	return &Store{runs: make(map[string]*Run)}
+}
+
+// OnRecord registers fn to be called with every run recorded from now on
+func (s *Store) OnRecord(fn func(*Run)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// OnRemove registers fn to be called with every run removed by the
+// retention limits from now on
+func (s *Store) OnRemove(fn func(*Run)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removed = append(s.removed, fn)
+}
+
+// Record persists the result of a run of tool with the given arguments.
+// Standard output and error are written to the run directory unless they
+// were already spilled there.
+func (s *Store) Record(tool string, arguments json.RawMessage, result *tools.ToolResult) (*Run, error) {
+	id := result.RunID
+	if id == "" {
+		var err error
+		if id, err = executor.NewRunID(); err != nil {
+			return nil, err
+		}
+		result.RunID = id
+	}
+	dir, err := executor.RunDir(id)
+	if err != nil {
+		return nil, err
+	}
+	if result.StdoutFile == "" {
+		if err := writeOutput(filepath.Join(dir, StdoutFile), result.Stdout, result.StdoutEncoding); err != nil {
+			return nil, err
+		}
+	}
+	if result.StderrFile == "" && result.Stderr != "" {
+		if err := writeOutput(filepath.Join(dir, StderrFile), result.Stderr, result.StderrEncoding); err != nil {
+			return nil, err
+		}
+	}
+
+	// The output is only kept in its artifacts, not again in the metadata
+	stored := *result
+	if stored.StdoutFile == "" {
+		stored.StdoutFile = filepath.Join(dir, StdoutFile)
+	}
+	if stored.StderrFile == "" && result.Stderr != "" {
+		stored.StderrFile = filepath.Join(dir, StderrFile)
+	}
+	stored.Stdout, stored.StdoutEncoding = "", ""
+	stored.Stderr, stored.StderrEncoding = "", ""
+
+	run := &Run{
+		ID:         id,
+		Tool:       tool,
+		Target:     targetOf(arguments),
+		Arguments:  redactArguments(arguments),
+		FinishedAt: time.Now(),
+		Success:    result.Success,
+		Summary:    result.Summary,
+		Result:     &stored,
+	}
+	if run.Artifacts, err = artifacts(dir); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, MetadataFile), data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to save run: %w", err)
+	}
+
+	s.mu.Lock()
+	s.runs[id] = run
+	listeners := slices.Clone(s.listeners)
+	s.mu.Unlock()
+	for _, fn := range listeners {
+		fn(run)
+	}
+	s.prune()
+	s.removeOrphans()
+	return run, nil
+}
+
+// Load reads the runs persisted in the artifact directory by earlier
+// processes. Directories without run metadata are skipped, and removed
+// once they are older than OrphanAge.
+func (s *Store) Load() error {
+	entries, err := os.ReadDir(executor.ArtifactDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(executor.ArtifactDir, entry.Name(), MetadataFile))
+		if err != nil {
+			continue
+		}
+		var run Run
+		if err := json.Unmarshal(data, &run); err != nil || run.ID != entry.Name() {
+			log.Printf("Skipping run %s: invalid %s", entry.Name(), MetadataFile)
+			continue
+		}
+		s.mu.Lock()
+		s.runs[run.ID] = &run
+		s.mu.Unlock()
+	}
+	s.prune()
+	s.removeOrphans()
+	return nil
+}
+
+// removeOrphans removes the run directories without metadata that were not
+// modified for OrphanAge, which were left by runs that were never recorded
+func (s *Store) removeOrphans() {
+	entries, err := os.ReadDir(executor.ArtifactDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !runIDPattern.MatchString(entry.Name()) {
+			continue
+		}
+		s.mu.Lock()
+		_, recorded := s.runs[entry.Name()]
+		s.mu.Unlock()
+		if recorded {
+			continue
+		}
+		dir := filepath.Join(executor.ArtifactDir, entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, MetadataFile)); !os.IsNotExist(err) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < OrphanAge {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("Failed to remove unrecorded run %s: %v", entry.Name(), err)
+		}
+	}
+}
+
+// prune removes the runs beyond the retention limits and their artifacts
+func (s *Store) prune() {
+	if MaxRuns <= 0 && MaxAge <= 0 {
+		return
+	}
+	list := s.List("")
+	var expired []*Run
+	for i, run := range list {
+		if (MaxRuns > 0 && i >= MaxRuns) || (MaxAge > 0 && time.Since(run.FinishedAt) > MaxAge) {
+			expired = append(expired, run)
+		}
+	}
+	if len(expired) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	for _, run := range expired {
+		delete(s.runs, run.ID)
+	}
+	removed := slices.Clone(s.removed)
+	s.mu.Unlock()
+
+	for _, run := range expired {
+		if err := os.RemoveAll(filepath.Join(executor.ArtifactDir, run.ID)); err != nil {
+			log.Printf("Failed to remove run %s: %v", run.ID, err)
+		}
+		for _, fn := range removed {
+			fn(run)
+		}
+	}
+}
+
+// Get returns the run with the given ID
+func (s *Store) Get(id string) (*Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run, ok := s.runs[id]
+	if !ok {
+		return nil, fmt.Errorf("run not found: %s", id)
+	}
+	return run, nil
+}
+
+// List returns the runs of target, or all runs if target is empty, the
+// most recent first
+func (s *Store) List(target string) []*Run {
+	s.mu.Lock()
+	list := make([]*Run, 0, len(s.runs))
+	for _, run := range s.runs {
+		if target == "" || strings.EqualFold(run.Target, target) {
+			list = append(list, run)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].FinishedAt.After(list[j].FinishedAt) })
+	return list
+}
+
+// Path returns the path of an artifact of a run. Only the artifacts
+// listed in the run can be read, so names cannot leave its directory.
+func (s *Store) Path(id, name string) (string, error) {
+	run, err := s.Get(id)
+	if err != nil {
+		return "", err
+	}
+	for _, artifact := range run.Artifacts {
+		if artifact == name {
+			return filepath.Join(executor.ArtifactDir, run.ID, filepath.FromSlash(name)), nil
+		}
+	}
+	return "", fmt.Errorf("run %s has no artifact %s", id, name)
+}
+
+// writeOutput writes captured output to path, decoding base64 output
+func writeOutput(path, output, encoding string) error {
+	data := []byte(output)
+	if encoding == executor.EncodingBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(output)
+		if err == nil {
+			data = decoded
+		}
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save output: %w", err)
+	}
+	return nil
+}
+
+// artifacts lists the files in a run directory other than the metadata,
+// including files in sub directories such as sqlmap's output directory
+func artifacts(dir string) ([]string, error) {
+	names := []string{}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if name != MetadataFile {
+			names = append(names, filepath.ToSlash(name))
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names, err
+}
@@ -0,0 +1,72 @@
+package runs
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestRedactArguments redacts secrets in the arguments of tool runs
+func TestRedactArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments string
+		want      string
+	}{
+		{
+			name:      "hydra",
+			arguments: `{"target":"10.0.0.5","service":"ssh","username":"root","password":"toor","password_file":"/tmp/p.txt"}`,
+			want:      `{"target":"10.0.0.5","service":"ssh","username":"root","password":"[REDACTED]","password_file":"/tmp/p.txt"}`,
+		},
+		{
+			name:      "execute_command",
+			arguments: `{"command":"mysql -u root -psecret -e 'show databases'","timeout_seconds":60}`,
+			want:      `{"command":"[REDACTED]","timeout_seconds":60}`,
+		},
+		{
+			name:      "sqlmap",
+			arguments: `{"url":"http://10.0.0.5/login.php","data":"user=admin&pass=admin","additional_args":"--level 3 --cookie='PHPSESSID=abc; security=low' --auth-cred=admin:admin --risk=2"}`,
+			want:      `{"url":"http://10.0.0.5/login.php","data":"[REDACTED]","additional_args":"--level 3 --cookie=[REDACTED] --auth-cred=[REDACTED] --risk=2"}`,
+		},
+		{
+			name:      "wpscan",
+			arguments: `{"url":"http://10.0.0.5/","additional_args":"--enumerate u --api-token 0123abcd --headers \"Authorization: Bearer x\""}`,
+			want:      `{"url":"http://10.0.0.5/","additional_args":"--enumerate u --api-token [REDACTED] --headers [REDACTED]"}`,
+		},
+		{
+			name:      "metasploit",
+			arguments: `{"module":"auxiliary/scanner/smb/smb_login","options":{"RHOSTS":"10.0.0.5","SMBUser":"administrator","SMBPass":"P@ss","PASS_FILE":"/tmp/p.txt"}}`,
+			want:      `{"module":"auxiliary/scanner/smb/smb_login","options":{"RHOSTS":"10.0.0.5","SMBUser":"administrator","SMBPass":"[REDACTED]","PASS_FILE":"/tmp/p.txt"}}`,
+		},
+		{
+			name:      "nothing secret",
+			arguments: `{"target":"10.0.0.5","scan_type":"-sV","ports":"22,80"}`,
+			want:      `{"target":"10.0.0.5","scan_type":"-sV","ports":"22,80"}`,
+		},
+		{
+			name:      "invalid",
+			arguments: `not json`,
+			want:      `not json`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redactArguments(json.RawMessage(tt.arguments))
+			if !json.Valid([]byte(tt.want)) {
+				if string(got) != tt.want {
+					t.Errorf("got %s, want %s", got, tt.want)
+				}
+				return
+			}
+			var gotArgs, wantArgs interface{}
+			if err := json.Unmarshal(got, &gotArgs); err != nil {
+				t.Fatalf("invalid JSON %s: %v", got, err)
+			}
+			json.Unmarshal([]byte(tt.want), &wantArgs)
+			if !reflect.DeepEqual(gotArgs, wantArgs) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -216,6 +216,15 @@ func ValidateEnum(input string, allowedValues []string) error {
 	return fmt.Errorf("invalid value: %s (allowed: %v)", input, allowedValues)
 }
 
+// ValidatePositionalArg ensures a value passed as a positional argument
+// cannot be interpreted as an option by the invoked tool
+func ValidatePositionalArg(name, value string) error {
+	if strings.HasPrefix(strings.TrimSpace(value), "-") {
+		return fmt.Errorf("%s must not start with '-': %s", name, value)
+	}
+	return nil
+}
+
 // Helper function to parse integers safely
 func parseInt(s string) int {
 	var n int
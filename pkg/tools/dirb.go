@@ -1,45 +1,44 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 
-	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/security"
 )
 
 // DirbParams represents parameters for Dirb scan
 type DirbParams struct {
-	URL            string `json:"url"`
-	Wordlist       string `json:"wordlist"`
-	AdditionalArgs string `json:"additional_args"`
+	URL            string `json:"url" jsonschema:"Target URL" example:"http://example.com"`
+	Wordlist       string `json:"wordlist,omitempty" jsonschema:"Path of the wordlist on the server" default:"/usr/share/wordlists/dirb/common.txt"`
+	AdditionalArgs string `json:"additional_args,omitempty" jsonschema:"Additional Dirb arguments" example:"-X .php,.html"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, defaults to the tool timeout and is capped by its maximum" example:"1800"`
 }
 
 // DirbScan executes Dirb with the provided parameters
-func DirbScan(params DirbParams) (*ToolResult, error) {
+func DirbScan(ctx context.Context, params DirbParams) (*ToolResult, error) {
 	if params.URL == "" {
 		return nil, fmt.Errorf("URL parameter is required")
 	}
+	if err := security.ValidatePositionalArg("url", params.URL); err != nil {
+		return nil, err
+	}
 
 	// Default values
 	if params.Wordlist == "" {
 		params.Wordlist = "/usr/share/wordlists/dirb/common.txt"
 	}
 
-	command := fmt.Sprintf("dirb %s %s", params.URL, params.Wordlist)
-	if params.AdditionalArgs != "" {
-		command += fmt.Sprintf(" %s", params.AdditionalArgs)
+	args, err := appendExtraArgs([]string{params.URL, params.Wordlist}, params.AdditionalArgs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid additional_args: %w", err)
 	}
 
-	result, err := executor.ExecuteCommand(command)
+	result, err := runTool(ctx, Timeout("dirb_scan", params.TimeoutSeconds), "dirb", args)
 	if err != nil {
 		return nil, err
 	}
-
-	return &ToolResult{
-		Stdout:         result.Stdout,
-		Stderr:         result.Stderr,
-		Success:        result.Success,
-		ReturnCode:     result.ReturnCode,
-		TimedOut:       result.TimedOut,
-		PartialResults: result.PartialResults,
-	}, nil
+	setDiscoveryResult(result, "Dirb", false, parsers.ParseDirbOutput(fullStdout(result)))
+	return result, nil
 }
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"container/list"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
+)
+
+// maxDiscovered caps the URLs and subdomains remembered by discovered. The
+// least recently found are forgotten first, and are flagged as new again
+// if a later run finds them.
+const maxDiscovered = 100000
+
+// discovered remembers the URLs and subdomains found by content discovery
+// runs, so that results can be flagged as new across runs. The most
+// recently found are at the front of order.
+var discovered = struct {
+	mu    sync.Mutex
+	seen  map[string]*list.Element
+	order *list.List
+}{seen: make(map[string]*list.Element), order: list.New()}
+
+// markDiscovered flags the results of d that no earlier run has found
+// and returns how many there are
+func markDiscovered(d *parsers.ContentDiscovery) int {
+	discovered.mu.Lock()
+	defer discovered.mu.Unlock()
+
+	count := 0
+	mark := func(key string) bool {
+		if e, ok := discovered.seen[key]; ok {
+			discovered.order.MoveToFront(e)
+			return false
+		}
+		discovered.seen[key] = discovered.order.PushFront(key)
+		if discovered.order.Len() > maxDiscovered {
+			oldest := discovered.order.Back()
+			discovered.order.Remove(oldest)
+			delete(discovered.seen, oldest.Value.(string))
+		}
+		count++
+		return true
+	}
+	for i := range d.Paths {
+		d.Paths[i].New = mark("url\x00" + urlKey(d.Paths[i].URL))
+	}
+	for i := range d.Subdomains {
+		d.Subdomains[i].New = mark("host\x00" + strings.ToLower(d.Subdomains[i].Subdomain))
+	}
+	return count
+}
+
+// urlKey normalizes a URL for comparison. Only the scheme and host are case
+// insensitive, paths are not: /Admin and /admin are different findings.
+func urlKey(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	return u.String()
+}
+
+// setDiscoveryResult attaches the parsed content discovery results to result.
+// subdomains selects the wording of the summary when nothing was found.
+func setDiscoveryResult(result *ToolResult, tool string, subdomains bool, d *parsers.ContentDiscovery) {
+	newCount := markDiscovered(d)
+	result.Parsed = d
+	if len(d.Subdomains) > 0 || len(d.Paths) == 0 && subdomains {
+		summarize(result, fmt.Sprintf("%s found %d subdomains (%d new)", tool, len(d.Subdomains), newCount))
+	} else {
+		summarize(result, fmt.Sprintf("%s found %d paths (%d new)", tool, len(d.Paths), newCount))
+	}
+}
@@ -1,41 +1,81 @@
 package tools
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
 
-	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/security"
 )
 
 // Enum4linuxParams represents parameters for Enum4linux
 type Enum4linuxParams struct {
-	Target         string `json:"target"`
-	AdditionalArgs string `json:"additional_args"`
+	Target         string `json:"target" jsonschema:"Target host name or IP address" example:"192.168.1.10"`
+	AdditionalArgs string `json:"additional_args,omitempty" jsonschema:"Enum4linux arguments, -a runs enum4linux-ng when it is installed" default:"-a" example:"-U -S"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, defaults to the tool timeout and is capped by its maximum" example:"600"`
 }
 
 // Enum4linuxScan executes Enum4linux with the provided parameters
-func Enum4linuxScan(params Enum4linuxParams) (*ToolResult, error) {
+func Enum4linuxScan(ctx context.Context, params Enum4linuxParams) (*ToolResult, error) {
 	if params.Target == "" {
 		return nil, fmt.Errorf("target parameter is required")
 	}
+	if err := security.ValidatePositionalArg("target", params.Target); err != nil {
+		return nil, err
+	}
+
+	// A full scan uses enum4linux-ng when it is installed, as its JSON
+	// report is more reliable to parse than the text output
+	if params.AdditionalArgs == "" || params.AdditionalArgs == "-a" {
+		if _, err := exec.LookPath("enum4linux-ng"); err == nil {
+			return enum4linuxNGScan(ctx, params)
+		}
+	}
 
 	// Default values
 	if params.AdditionalArgs == "" {
 		params.AdditionalArgs = "-a"
 	}
 
-	command := fmt.Sprintf("enum4linux %s %s", params.AdditionalArgs, params.Target)
+	args, err := appendExtraArgs(nil, params.AdditionalArgs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid additional_args: %w", err)
+	}
+	args = append(args, params.Target)
+
+	result, err := runTool(ctx, Timeout("enum4linux_scan", params.TimeoutSeconds), "enum4linux", args)
+	if err != nil {
+		return nil, err
+	}
+	report := parsers.ParseEnum4linuxOutput(fullStdout(result))
+	result.Parsed = report
+	summarize(result, report.Summary())
+	return result, nil
+}
 
-	result, err := executor.ExecuteCommand(command)
+// enum4linuxNGScan runs a full enum4linux-ng scan and parses its JSON report
+func enum4linuxNGScan(ctx context.Context, params Enum4linuxParams) (*ToolResult, error) {
+	runID, dir, err := newRun()
 	if err != nil {
 		return nil, err
 	}
+	// enum4linux-ng appends .json to the output base name
+	base := filepath.Join(dir, "enum4linux")
+	args := []string{"-A", "-oJ", base, params.Target}
 
-	return &ToolResult{
-		Stdout:         result.Stdout,
-		Stderr:         result.Stderr,
-		Success:        result.Success,
-		ReturnCode:     result.ReturnCode,
-		TimedOut:       result.TimedOut,
-		PartialResults: result.PartialResults,
-	}, nil
+	result, err := runToolInRun(ctx, Timeout("enum4linux_scan", params.TimeoutSeconds), runID, "enum4linux-ng", args)
+	if err != nil {
+		return nil, err
+	}
+	report, err := parsers.ParseEnum4linuxNGJSONFile(base + ".json")
+	if err != nil {
+		log.Printf("Failed to parse enum4linux-ng results: %v", err)
+		return result, nil
+	}
+	result.Parsed = report
+	summarize(result, report.Summary())
+	return result, nil
 }
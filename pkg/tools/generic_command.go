@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
@@ -8,26 +9,23 @@ import (
 
 // GenericCommandParams represents parameters for generic command execution
 type GenericCommandParams struct {
-	Command string `json:"command"`
+	Command        string `json:"command" jsonschema:"Command line, interpreted by the shell" example:"whois example.com | head -n 20"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, defaults to the global timeout and is capped by the tool maximum" example:"300"`
 }
 
-// ExecuteGenericCommand executes any command
-func ExecuteGenericCommand(params GenericCommandParams) (*ToolResult, error) {
+// ExecuteGenericCommand executes any command. Unlike the other tools the
+// command line is interpreted by the shell, so pipes, redirections and
+// expansions are available to the caller.
+func ExecuteGenericCommand(ctx context.Context, params GenericCommandParams) (*ToolResult, error) {
 	if params.Command == "" {
 		return nil, fmt.Errorf("command parameter is required")
 	}
 
-	result, err := executor.ExecuteCommand(params.Command)
+	timeout := Timeout("execute_command", params.TimeoutSeconds)
+	result, err := executor.NewCommandExecutor(params.Command, timeout).ExecuteContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return &ToolResult{
-		Stdout:         result.Stdout,
-		Stderr:         result.Stderr,
-		Success:        result.Success,
-		ReturnCode:     result.ReturnCode,
-		TimedOut:       result.TimedOut,
-		PartialResults: result.PartialResults,
-	}, nil
+	return newToolResult(result), nil
 }
@@ -1,22 +1,24 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 
-	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
 	"github.com/ba0f3/MCP-Kali-Server/pkg/helpers"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
 )
 
 // GobusterParams represents parameters for Gobuster scan
 type GobusterParams struct {
-	URL            string `json:"url"`
-	Mode           string `json:"mode"`
-	Wordlist       string `json:"wordlist"`
-	AdditionalArgs string `json:"additional_args"`
+	URL            string `json:"url" jsonschema:"Target URL, or the domain in dns mode" example:"http://example.com"`
+	Mode           string `json:"mode,omitempty" jsonschema:"Gobuster mode" enum:"dir,dns,fuzz,vhost" default:"dir"`
+	Wordlist       string `json:"wordlist,omitempty" jsonschema:"Path of the wordlist on the server" default:"/usr/share/wordlists/dirb/common.txt"`
+	AdditionalArgs string `json:"additional_args,omitempty" jsonschema:"Additional Gobuster arguments" example:"-x php,html -t 50"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, defaults to the tool timeout and is capped by its maximum" example:"1800"`
 }
 
 // GobusterScan executes Gobuster with the provided parameters
-func GobusterScan(params GobusterParams) (*ToolResult, error) {
+func GobusterScan(ctx context.Context, params GobusterParams) (*ToolResult, error) {
 	if params.URL == "" {
 		return nil, fmt.Errorf("URL parameter is required")
 	}
@@ -29,7 +31,13 @@ func GobusterScan(params GobusterParams) (*ToolResult, error) {
 		params.Wordlist = "/usr/share/wordlists/dirb/common.txt"
 	}
 
-	var param string
+	// Validate mode
+	validModes := map[string]bool{"dir": true, "dns": true, "fuzz": true, "vhost": true}
+	if !validModes[params.Mode] {
+		return nil, fmt.Errorf("invalid mode: %s. Must be one of: dir, dns, fuzz, vhost", params.Mode)
+	}
+
+	args := []string{params.Mode}
 	if params.Mode == "dns" {
 		domain, err := helpers.ParseDomain(params.URL)
 		if err != nil {
@@ -38,33 +46,21 @@ func GobusterScan(params GobusterParams) (*ToolResult, error) {
 		if domain == "" {
 			return nil, fmt.Errorf("invalid URL: %s", params.URL)
 		}
-		param = "-d " + domain
+		args = append(args, "-d", domain)
 	} else {
-		param = "-u " + params.URL
-	}
-
-	// Validate mode
-	validModes := map[string]bool{"dir": true, "dns": true, "fuzz": true, "vhost": true}
-	if !validModes[params.Mode] {
-		return nil, fmt.Errorf("invalid mode: %s. Must be one of: dir, dns, fuzz, vhost", params.Mode)
+		args = append(args, "-u", params.URL)
 	}
+	args = append(args, "-w", params.Wordlist)
 
-	command := fmt.Sprintf("gobuster %s %s -w %s", params.Mode, param, params.Wordlist)
-	if params.AdditionalArgs != "" {
-		command += fmt.Sprintf(" %s", params.AdditionalArgs)
+	args, err := appendExtraArgs(args, params.AdditionalArgs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid additional_args: %w", err)
 	}
 
-	result, err := executor.ExecuteCommand(command)
+	result, err := runTool(ctx, Timeout("gobuster_scan", params.TimeoutSeconds), "gobuster", args)
 	if err != nil {
 		return nil, err
 	}
-
-	return &ToolResult{
-		Stdout:         result.Stdout,
-		Stderr:         result.Stderr,
-		Success:        result.Success,
-		ReturnCode:     result.ReturnCode,
-		TimedOut:       result.TimedOut,
-		PartialResults: result.PartialResults,
-	}, nil
+	setDiscoveryResult(result, "Gobuster", params.Mode == "dns" || params.Mode == "vhost", parsers.ParseGobusterOutput(params.Mode, params.URL, fullStdout(result)))
+	return result, nil
 }
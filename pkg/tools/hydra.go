@@ -1,24 +1,30 @@
 package tools
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 
-	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/credentials"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/security"
 )
 
 // HydraParams represents parameters for Hydra attack
 type HydraParams struct {
-	Target         string `json:"target"`
-	Service        string `json:"service"`
-	Username       string `json:"username"`
-	UsernameFile   string `json:"username_file"`
-	Password       string `json:"password"`
-	PasswordFile   string `json:"password_file"`
-	AdditionalArgs string `json:"additional_args"`
+	Target         string `json:"target" jsonschema:"Target host name or IP address" example:"192.168.1.10"`
+	Service        string `json:"service" jsonschema:"Service to attack as Hydra names it" example:"ssh"`
+	Username       string `json:"username,omitempty" jsonschema:"Single username to try, username or username_file is required" example:"admin"`
+	UsernameFile   string `json:"username_file,omitempty" jsonschema:"Path of a username list on the server" example:"/usr/share/wordlists/metasploit/unix_users.txt"`
+	Password       string `json:"password,omitempty" jsonschema:"Single password to try, password or password_file is required" example:"password123"`
+	PasswordFile   string `json:"password_file,omitempty" jsonschema:"Path of a password list on the server" example:"/usr/share/wordlists/rockyou.txt"`
+	AdditionalArgs string `json:"additional_args,omitempty" jsonschema:"Additional Hydra arguments" example:"-s 2222 -f"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, defaults to the tool timeout and is capped by its maximum" example:"3600"`
 }
 
 // HydraAttack executes Hydra with the provided parameters
-func HydraAttack(params HydraParams) (*ToolResult, error) {
+func HydraAttack(ctx context.Context, params HydraParams) (*ToolResult, error) {
 	if params.Target == "" {
 		return nil, fmt.Errorf("target parameter is required")
 	}
@@ -31,38 +37,61 @@ func HydraAttack(params HydraParams) (*ToolResult, error) {
 	if params.Password == "" && params.PasswordFile == "" {
 		return nil, fmt.Errorf("password or password_file parameter is required")
 	}
+	if err := security.ValidatePositionalArg("target", params.Target); err != nil {
+		return nil, err
+	}
+	if err := security.ValidatePositionalArg("service", params.Service); err != nil {
+		return nil, err
+	}
 
-	command := "hydra -t 4"
+	args := []string{"-t", "4"}
 
 	if params.Username != "" {
-		command += fmt.Sprintf(" -l %s", params.Username)
+		args = append(args, "-l", params.Username)
 	} else {
-		command += fmt.Sprintf(" -L %s", params.UsernameFile)
+		args = append(args, "-L", params.UsernameFile)
 	}
 
 	if params.Password != "" {
-		command += fmt.Sprintf(" -p %s", params.Password)
+		args = append(args, "-p", params.Password)
 	} else {
-		command += fmt.Sprintf(" -P %s", params.PasswordFile)
+		args = append(args, "-P", params.PasswordFile)
 	}
 
-	if params.AdditionalArgs != "" {
-		command += fmt.Sprintf(" %s", params.AdditionalArgs)
+	args, err := appendExtraArgs(args, params.AdditionalArgs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid additional_args: %w", err)
 	}
 
-	command += fmt.Sprintf(" %s %s", params.Target, params.Service)
+	// Also write the results to a file, which is not subject to output truncation
+	runID, dir, err := newRun()
+	if err != nil {
+		return nil, err
+	}
+	resultPath := filepath.Join(dir, "hydra.txt")
+	args = append(args, "-o", resultPath, params.Target, params.Service)
 
-	result, err := executor.ExecuteCommand(command)
+	result, err := runToolInRun(ctx, Timeout("hydra_attack", params.TimeoutSeconds), runID, "hydra", args)
 	if err != nil {
 		return nil, err
 	}
 
-	return &ToolResult{
-		Stdout:         result.Stdout,
-		Stderr:         result.Stderr,
-		Success:        result.Success,
-		ReturnCode:     result.ReturnCode,
-		TimedOut:       result.TimedOut,
-		PartialResults: result.PartialResults,
-	}, nil
+	output := fullStdout(result)
+	if data, err := os.ReadFile(resultPath); err == nil {
+		output = string(data)
+	}
+	found := parsers.ParseHydraOutput(output)
+	for _, cred := range found {
+		credentials.DefaultStore.Add(credentials.Credential{
+			Host:     cred.Host,
+			Port:     cred.Port,
+			Service:  cred.Service,
+			Username: cred.Login,
+			Password: cred.Password,
+			Source:   "hydra",
+		})
+	}
+	result.Parsed = found
+	summarize(result, fmt.Sprintf("Hydra found %d valid credentials", len(found)))
+	return result, nil
 }
@@ -1,57 +1,110 @@
 package tools
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
 
-	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/credentials"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/security"
 )
 
+// johnShowTimeout limits the "john --show" run that collects cracked passwords
+const johnShowTimeout = time.Minute
+
 // JohnParams represents parameters for John the Ripper
 type JohnParams struct {
-	HashFile       string `json:"hash_file"`
-	Wordlist       string `json:"wordlist"`
-	Format         string `json:"format"`
-	AdditionalArgs string `json:"additional_args"`
+	HashFile       string `json:"hash_file" jsonschema:"Path of the file with the hashes on the server" example:"/tmp/hashes.txt"`
+	Wordlist       string `json:"wordlist,omitempty" jsonschema:"Path of the wordlist on the server" default:"/usr/share/wordlists/rockyou.txt"`
+	Format         string `json:"format,omitempty" jsonschema:"Hash format, detected by John when empty" example:"raw-md5"`
+	AdditionalArgs string `json:"additional_args,omitempty" jsonschema:"Additional John arguments" example:"--rules"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, defaults to the tool timeout and is capped by its maximum" example:"7200"`
 }
 
 // JohnCrack executes John the Ripper with the provided parameters
-func JohnCrack(params JohnParams) (*ToolResult, error) {
+func JohnCrack(ctx context.Context, params JohnParams) (*ToolResult, error) {
 	if params.HashFile == "" {
 		return nil, fmt.Errorf("hash_file parameter is required")
 	}
+	if err := security.ValidatePositionalArg("hash_file", params.HashFile); err != nil {
+		return nil, err
+	}
 
 	// Default values
 	if params.Wordlist == "" {
 		params.Wordlist = "/usr/share/wordlists/rockyou.txt"
 	}
 
-	command := "john"
+	var args []string
 
 	if params.Format != "" {
-		command += fmt.Sprintf(" --format=%s", params.Format)
+		args = append(args, "--format="+params.Format)
 	}
 
 	if params.Wordlist != "" {
-		command += fmt.Sprintf(" --wordlist=%s", params.Wordlist)
+		args = append(args, "--wordlist="+params.Wordlist)
 	}
 
-	if params.AdditionalArgs != "" {
-		command += fmt.Sprintf(" %s", params.AdditionalArgs)
+	args, err := appendExtraArgs(args, params.AdditionalArgs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid additional_args: %w", err)
 	}
 
-	command += fmt.Sprintf(" %s", params.HashFile)
+	// Label bare hashes in a copy of the hash file, so that the passwords
+	// cracked for them can be told apart
+	runID, dir, err := newRun()
+	if err != nil {
+		return nil, err
+	}
+	hashFile := params.HashFile
+	var hashes map[string]string
+	if data, err := os.ReadFile(params.HashFile); err == nil {
+		content, labelled := parsers.LabelJohnHashes(string(data))
+		if labelled {
+			hashFile = filepath.Join(dir, "hashes.txt")
+			if err := os.WriteFile(hashFile, []byte(content), 0o600); err != nil {
+				discardRun(runID)
+				return nil, fmt.Errorf("failed to write hash file: %w", err)
+			}
+		}
+		hashes = parsers.ParseJohnHashFile(content)
+	}
+	args = append(args, hashFile)
 
-	result, err := executor.ExecuteCommand(command)
+	result, err := runToolInRun(ctx, Timeout("john_crack", params.TimeoutSeconds), runID, "john", args)
 	if err != nil {
 		return nil, err
 	}
+	if result.Canceled {
+		return result, nil
+	}
 
-	return &ToolResult{
-		Stdout:         result.Stdout,
-		Stderr:         result.Stderr,
-		Success:        result.Success,
-		ReturnCode:     result.ReturnCode,
-		TimedOut:       result.TimedOut,
-		PartialResults: result.PartialResults,
-	}, nil
+	// Ask john for everything cracked so far, even if the run timed out
+	showArgs := []string{"--show"}
+	if params.Format != "" {
+		showArgs = append(showArgs, "--format="+params.Format)
+	}
+	showArgs = append(showArgs, hashFile)
+	show, err := runTool(ctx, johnShowTimeout, "john", showArgs)
+	if err != nil {
+		log.Printf("Failed to run john --show: %v", err)
+		return result, nil
+	}
+
+	cracked := parsers.ParseJohnShow(fullStdout(show), hashes)
+	for _, c := range cracked.Cracked {
+		credentials.DefaultStore.Add(credentials.Credential{
+			Username: c.Username,
+			Password: c.Password,
+			Hash:     c.Hash,
+			Source:   "john",
+		})
+	}
+	result.Parsed = cracked
+	summarize(result, fmt.Sprintf("John cracked %d password hashes, %d left", len(cracked.Cracked), cracked.Left))
+	return result, nil
 }
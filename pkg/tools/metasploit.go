@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// MetasploitParams represents parameters for a Metasploit module run
+type MetasploitParams struct {
+	Module         string                 `json:"module" jsonschema:"Metasploit module path" example:"auxiliary/scanner/smb/smb_version"`
+	Options        map[string]interface{} `json:"options,omitempty" jsonschema:"Module options to set, by name" example:"{\"RHOSTS\":\"192.168.1.10\"}"`
+	TimeoutSeconds int                    `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, defaults to the tool timeout and is capped by its maximum" example:"600"`
+}
+
+// MetasploitRun executes a Metasploit module through a generated resource script
+func MetasploitRun(ctx context.Context, params MetasploitParams) (*ToolResult, error) {
+	if params.Module == "" {
+		return nil, fmt.Errorf("module parameter is required")
+	}
+	if strings.ContainsAny(params.Module, "\r\n") {
+		return nil, fmt.Errorf("module must not contain line breaks")
+	}
+
+	// Sort option names so the resource script is deterministic
+	keys := make([]string, 0, len(params.Options))
+	for key := range params.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Format options, rejecting anything that would inject extra console commands
+	var optionsStr strings.Builder
+	for _, key := range keys {
+		value := fmt.Sprintf("%v", params.Options[key])
+		if strings.ContainsAny(key, " \t\r\n") || strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("invalid option %q", key)
+		}
+		optionsStr.WriteString(fmt.Sprintf(" set %s %s\n", key, value))
+	}
+
+	resourceContent := fmt.Sprintf("use %s\n%s exploit\n", params.Module, optionsStr.String())
+	tempFile, err := os.CreateTemp("", "mcp_msf_resource_*.rc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.WriteString(resourceContent); err != nil {
+		tempFile.Close()
+		return nil, fmt.Errorf("failed to write resource file: %w", err)
+	}
+	tempFile.Close()
+
+	return runTool(ctx, Timeout("metasploit_run", params.TimeoutSeconds), "msfconsole", []string{"-q", "-r", tempFile.Name()})
+}
@@ -1,39 +1,27 @@
 package tools
 
 import (
+	"context"
 	"fmt"
-
-	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
 )
 
 // NiktoParams represents parameters for Nikto scan
 type NiktoParams struct {
-	Target         string `json:"target"`
-	AdditionalArgs string `json:"additional_args"`
+	Target         string `json:"target" jsonschema:"Target host or URL" example:"http://example.com"`
+	AdditionalArgs string `json:"additional_args,omitempty" jsonschema:"Additional Nikto arguments" example:"-Tuning 123b"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, defaults to the tool timeout and is capped by its maximum" example:"1800"`
 }
 
 // NiktoScan executes Nikto with the provided parameters
-func NiktoScan(params NiktoParams) (*ToolResult, error) {
+func NiktoScan(ctx context.Context, params NiktoParams) (*ToolResult, error) {
 	if params.Target == "" {
 		return nil, fmt.Errorf("target parameter is required")
 	}
 
-	command := fmt.Sprintf("nikto -h %s", params.Target)
-	if params.AdditionalArgs != "" {
-		command += fmt.Sprintf(" %s", params.AdditionalArgs)
-	}
-
-	result, err := executor.ExecuteCommand(command)
+	args, err := appendExtraArgs([]string{"-h", params.Target}, params.AdditionalArgs)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid additional_args: %w", err)
 	}
 
-	return &ToolResult{
-		Stdout:         result.Stdout,
-		Stderr:         result.Stderr,
-		Success:        result.Success,
-		ReturnCode:     result.ReturnCode,
-		TimedOut:       result.TimedOut,
-		PartialResults: result.PartialResults,
-	}, nil
+	return runTool(ctx, Timeout("nikto_scan", params.TimeoutSeconds), "nikto", args)
 }
@@ -1,21 +1,33 @@
 package tools
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
 
-	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/helpers"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/security"
 )
 
 // NmapParams represents parameters for Nmap scan
 type NmapParams struct {
-	Target         string `json:"target"`
-	ScanType       string `json:"scan_type"`
-	Ports          string `json:"ports"`
-	AdditionalArgs string `json:"additional_args"`
+	Target         string `json:"target" jsonschema:"Host name, IP address or CIDR range to scan" example:"192.168.1.0/24"`
+	ScanType       string `json:"scan_type,omitempty" jsonschema:"Nmap scan type flags" default:"-sCV" example:"-sS"`
+	Ports          string `json:"ports,omitempty" jsonschema:"Ports or port ranges to scan, all of Nmap's top ports when empty" example:"22,80,443,8000-8100"`
+	AdditionalArgs string `json:"additional_args,omitempty" jsonschema:"Additional Nmap arguments" default:"-T4 -Pn" example:"-T4 -Pn --open"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, defaults to the tool timeout and is capped by its maximum" example:"1800"`
 }
 
+// nmapStatsInterval is how often Nmap prints its progress when progress
+// is reported
+const nmapStatsInterval = "10s"
+
 // NmapScan executes an Nmap scan with the provided parameters
-func NmapScan(params NmapParams) (*ToolResult, error) {
+func NmapScan(ctx context.Context, params NmapParams) (*ToolResult, error) {
 	if params.Target == "" {
 		return nil, fmt.Errorf("target parameter is required")
 	}
@@ -28,26 +40,71 @@ func NmapScan(params NmapParams) (*ToolResult, error) {
 		params.AdditionalArgs = "-T4 -Pn"
 	}
 
-	command := fmt.Sprintf("nmap %s", params.ScanType)
+	args, err := helpers.SplitArgs(params.ScanType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scan_type: %w", err)
+	}
 	if params.Ports != "" {
-		command += fmt.Sprintf(" -p %s", params.Ports)
+		args = append(args, "-p", params.Ports)
 	}
-	if params.AdditionalArgs != "" {
-		command += fmt.Sprintf(" %s", params.AdditionalArgs)
+	args, err = appendExtraArgs(args, params.AdditionalArgs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid additional_args: %w", err)
 	}
-	command += fmt.Sprintf(" %s", params.Target)
 
-	result, err := executor.ExecuteCommand(command)
+	// Multiple targets may be given separated by whitespace
+	for _, target := range strings.Fields(params.Target) {
+		if err := security.ValidatePositionalArg("target", target); err != nil {
+			return nil, err
+		}
+		args = append(args, target)
+	}
+
+	// Print the scan progress periodically for progress notifications
+	if progressWanted(ctx) && !slices.Contains(args, "--stats-every") {
+		args = append([]string{"--stats-every", nmapStatsInterval}, args...)
+	}
+
+	// Always write an XML report to parse, unless the caller asked for one
+	xmlPath := nmapXMLOutput(args)
+	runID, dir, err := newRun()
 	if err != nil {
 		return nil, err
 	}
+	if xmlPath == "" {
+		xmlPath = filepath.Join(dir, "nmap.xml")
+		args = append([]string{"-oX", xmlPath}, args...)
+	}
 
-	return &ToolResult{
-		Stdout:         result.Stdout,
-		Stderr:         result.Stderr,
-		Success:        result.Success,
-		ReturnCode:     result.ReturnCode,
-		TimedOut:       result.TimedOut,
-		PartialResults: result.PartialResults,
-	}, nil
+	result, err := runToolInRun(ctx, Timeout("nmap_scan", params.TimeoutSeconds), runID, "nmap", args)
+	if err != nil {
+		return nil, err
+	}
+
+	if xmlPath != "-" {
+		run, err := parsers.ParseNmapXMLFile(xmlPath)
+		if err != nil {
+			log.Printf("Failed to parse nmap XML report: %v", err)
+		} else {
+			result.Parsed = run
+		}
+	}
+	return result, nil
+}
+
+// nmapXMLOutput returns the XML report path already present in args, if any
+func nmapXMLOutput(args []string) string {
+	for i, arg := range args {
+		switch {
+		case arg == "-oX" && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(arg, "-oX") && len(arg) > 3:
+			return arg[3:]
+		case arg == "-oA" && i+1 < len(args):
+			return args[i+1] + ".xml"
+		case strings.HasPrefix(arg, "-oA") && len(arg) > 3:
+			return arg[3:] + ".xml"
+		}
+	}
+	return ""
 }
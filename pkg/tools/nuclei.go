@@ -1,42 +1,39 @@
 package tools
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
 
-	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
-	"github.com/ba0f3/MCP-Kali-Server/pkg/security"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
 )
 
 // NucleiParams represents parameters for Nuclei scan
 type NucleiParams struct {
-	Target         string `json:"target"`
-	Templates      string `json:"templates"`
-	Severity       string `json:"severity"`
-	Tags           string `json:"tags"`
-	AdditionalArgs string `json:"additional_args"`
+	Target         string `json:"target" jsonschema:"Target URL or host" example:"https://example.com"`
+	Templates      string `json:"templates,omitempty" jsonschema:"Templates or template directories to run, all default templates when empty" example:"http/cves/"`
+	Severity       string `json:"severity,omitempty" jsonschema:"Run only templates of this severity" enum:"info,low,medium,high,critical"`
+	Tags           string `json:"tags,omitempty" jsonschema:"Comma separated template tags to run" example:"cve,rce"`
+	AdditionalArgs string `json:"additional_args,omitempty" jsonschema:"Additional Nuclei arguments" example:"-rate-limit 50"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, defaults to the tool timeout and is capped by its maximum" example:"1800"`
 }
 
 // NucleiScan executes Nuclei with the provided parameters
-func NucleiScan(params NucleiParams) (*ToolResult, error) {
+func NucleiScan(ctx context.Context, params NucleiParams) (*ToolResult, error) {
 	if params.Target == "" {
 		return nil, fmt.Errorf("target parameter is required")
 	}
 
-	// Sanitize arguments
-	params.Target = security.SanitizeInput(params.Target)
-	params.Templates = security.SanitizeInput(params.Templates)
-	params.Tags = security.SanitizeInput(params.Tags)
-	params.AdditionalArgs = security.SanitizeInput(params.AdditionalArgs)
-
-	// Build command
-	command := "nuclei"
-
 	// Add target
-	command += fmt.Sprintf(" -u %s", params.Target)
+	args := []string{"-u", params.Target}
 
 	// Add templates if specified
 	if params.Templates != "" {
-		command += fmt.Sprintf(" -t %s", params.Templates)
+		args = append(args, "-t", params.Templates)
 	}
 
 	// Add severity filter if specified
@@ -47,7 +44,7 @@ func NucleiScan(params NucleiParams) (*ToolResult, error) {
 			"high": true, "critical": true,
 		}
 		if validSeverities[params.Severity] {
-			command += fmt.Sprintf(" -s %s", params.Severity)
+			args = append(args, "-s", params.Severity)
 		} else {
 			return nil, fmt.Errorf("invalid severity level: %s. Must be one of: info, low, medium, high, critical", params.Severity)
 		}
@@ -55,28 +52,41 @@ func NucleiScan(params NucleiParams) (*ToolResult, error) {
 
 	// Add tags filter if specified
 	if params.Tags != "" {
-		command += fmt.Sprintf(" -tags %s", params.Tags)
+		args = append(args, "-tags", params.Tags)
 	}
 
 	// Add default flags for better output
-	command += " -silent -no-interactsh"
+	args = append(args, "-silent", "-no-interactsh")
 
 	// Add any additional arguments
-	if params.AdditionalArgs != "" {
-		command += fmt.Sprintf(" %s", params.AdditionalArgs)
+	args, err := appendExtraArgs(args, params.AdditionalArgs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid additional_args: %w", err)
+	}
+
+	// Export the findings as JSONL to parse them, keeping the text output readable
+	runID, dir, err := newRun()
+	if err != nil {
+		return nil, err
 	}
+	jsonlPath := filepath.Join(dir, "nuclei.jsonl")
+	args = append(args, "-jsonl-export", jsonlPath)
 
-	result, err := executor.ExecuteCommand(command)
+	result, err := runToolInRun(ctx, Timeout("nuclei_scan", params.TimeoutSeconds), runID, "nuclei", args)
 	if err != nil {
 		return nil, err
 	}
 
-	return &ToolResult{
-		Stdout:         result.Stdout,
-		Stderr:         result.Stderr,
-		Success:        result.Success,
-		ReturnCode:     result.ReturnCode,
-		TimedOut:       result.TimedOut,
-		PartialResults: result.PartialResults,
-	}, nil
+	report, err := parsers.ParseNucleiJSONLFile(jsonlPath)
+	if errors.Is(err, fs.ErrNotExist) && !result.Canceled {
+		// Nuclei does not create the export when nothing was found
+		report, err = parsers.ParseNucleiJSONL(strings.NewReader(""))
+	}
+	if err != nil {
+		log.Printf("Failed to parse nuclei results: %v", err)
+		return result, nil
+	}
+	result.Parsed = report
+	summarize(result, report.Summary())
+	return result, nil
 }
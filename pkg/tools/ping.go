@@ -1,31 +1,50 @@
 package tools
 
 import (
+	"context"
 	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
 	"github.com/ba0f3/MCP-Kali-Server/pkg/security"
 )
 
+const (
+	// DefaultPingConcurrency is the number of targets of a sweep pinged at once
+	DefaultPingConcurrency = 16
+	// MaxPingConcurrency caps the concurrency a sweep may ask for
+	MaxPingConcurrency = 64
+	// MaxPingTargets caps the number of hosts of a sweep
+	MaxPingTargets = 4096
+)
+
 // PingParams represents parameters for ping
 type PingParams struct {
-	Target         string `json:"target"`
-	Count          int    `json:"count"`
-	Timeout        int    `json:"timeout"`
-	PacketSize     int    `json:"packet_size"`
-	AdditionalArgs string `json:"additional_args"`
+	Target         string   `json:"target,omitempty" jsonschema:"Host, IP address or CIDR range to ping, target or targets is required" example:"192.168.1.1"`
+	Targets        []string `json:"targets,omitempty" jsonschema:"Further hosts, IP addresses or CIDR ranges to sweep" example:"192.168.1.10,10.0.0.0/28"`
+	Count          int      `json:"count,omitempty" jsonschema:"Number of echo requests per host" default:"4"`
+	Timeout        int      `json:"timeout,omitempty" jsonschema:"Seconds to wait for each reply" default:"5"`
+	PacketSize     int      `json:"packet_size,omitempty" jsonschema:"Payload size in bytes, at most 65507" example:"56"`
+	Concurrency    int      `json:"concurrency,omitempty" jsonschema:"Hosts pinged in parallel during a sweep, at most 64" default:"16"`
+	AdditionalArgs string   `json:"additional_args,omitempty" jsonschema:"Additional ping arguments" example:"-i 0.5"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, of the whole sweep when several targets are pinged, defaults to the tool timeout and is capped by its maximum" example:"60"`
 }
 
-// Ping executes ping command with the provided parameters
-func Ping(params PingParams) (*ToolResult, error) {
-	if params.Target == "" {
+// Ping executes ping command with the provided parameters. A single host
+// returns its statistics; several targets or a CIDR range are swept in
+// parallel and return the statistics of every host.
+func Ping(ctx context.Context, params PingParams) (*ToolResult, error) {
+	if params.Target == "" && len(params.Targets) == 0 {
 		return nil, fmt.Errorf("target parameter is required")
 	}
 
-	// Sanitize target
-	target, err := security.SanitizeTarget(params.Target)
+	targets, sweep, err := pingTargets(append([]string{params.Target}, params.Targets...))
 	if err != nil {
-		return nil, fmt.Errorf("invalid target: %v", err)
+		return nil, err
 	}
 
 	// Default values
@@ -36,43 +55,213 @@ func Ping(params PingParams) (*ToolResult, error) {
 		params.Timeout = 5
 	}
 
-	// Build command
-	command := "ping"
-
-	// Add count parameter
-	command += fmt.Sprintf(" -c %d", params.Count)
-
-	// Add timeout parameter
-	command += fmt.Sprintf(" -W %d", params.Timeout)
+	// Add count and timeout parameters
+	args := []string{
+		"-c", strconv.Itoa(params.Count),
+		"-W", strconv.Itoa(params.Timeout),
+	}
 
 	// Add packet size if specified
 	if params.PacketSize > 0 {
 		if params.PacketSize > 65507 {
 			return nil, fmt.Errorf("packet size too large (max: 65507)")
 		}
-		command += fmt.Sprintf(" -s %d", params.PacketSize)
+		args = append(args, "-s", strconv.Itoa(params.PacketSize))
 	}
 
+	// Validate any additional arguments once for all targets
+	if _, err := appendExtraArgs(nil, params.AdditionalArgs); err != nil {
+		return nil, fmt.Errorf("invalid additional_args: %w", err)
+	}
+
+	if !sweep {
+		result, err := pingTarget(ctx, params, args, targets[0])
+		if err != nil {
+			return nil, err
+		}
+		parsed := parsers.ParsePingOutput(targets[0], fullStdout(result))
+		result.Parsed = parsed
+		summarize(result, parsed.Summary(), pingNoReply)
+		return result, nil
+	}
+	return pingSweep(ctx, params, args, targets), nil
+}
+
+// pingNoReply is the exit code of ping when the host did not answer
+const pingNoReply = 1
+
+// pingTarget pings a single target
+func pingTarget(ctx context.Context, params PingParams, args []string, target string) (*ToolResult, error) {
 	// Add target
-	command += fmt.Sprintf(" %s", target)
+	args = append(append([]string(nil), args...), target)
 
-	// Add any additional arguments (sanitized)
-	if params.AdditionalArgs != "" {
-		sanitizedArgs := security.SanitizeInput(params.AdditionalArgs)
-		command += fmt.Sprintf(" %s", sanitizedArgs)
+	// Add any additional arguments
+	args, err := appendExtraArgs(args, params.AdditionalArgs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid additional_args: %w", err)
 	}
 
-	result, err := executor.ExecuteCommand(command)
+	return runTool(ctx, Timeout("ping", params.TimeoutSeconds), "ping", args)
+}
+
+// pingSweep pings targets with bounded parallelism and combines the results.
+// The whole sweep is bounded by the tool timeout; hosts not pinged by then
+// are left out of the results. The sweep fails if any host could not be
+// pinged, which is different from a host not answering.
+func pingSweep(ctx context.Context, params PingParams, args []string, targets []string) *ToolResult {
+	sweepCtx, cancel := context.WithTimeout(ctx, Timeout("ping", params.TimeoutSeconds))
+	defer cancel()
+
+	concurrency := params.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultPingConcurrency
+	}
+	if concurrency > MaxPingConcurrency {
+		concurrency = MaxPingConcurrency
+	}
+	// More workers than the limiter runs at once would only wait in its
+	// queue, where they could crowd out other callers
+	if capacity := executor.DefaultLimiter.Capacity("ping"); capacity > 0 && concurrency > capacity {
+		concurrency = capacity
+	}
+
+	hosts := make([]parsers.PingResult, len(targets))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(targets); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result, err := pingTarget(sweepCtx, params, args, targets[i])
+				if err != nil {
+					hosts[i] = parsers.PingResult{Target: targets[i], Error: err.Error()}
+					continue
+				}
+				hosts[i] = *parsers.ParsePingOutput(targets[i], fullStdout(result))
+				if result.ReturnCode > pingNoReply && !result.TimedOut && !result.Canceled {
+					hosts[i].Error = pingError(result)
+				}
+			}
+		}()
+	}
+	started := 0
+feed:
+	for ; started < len(targets); started++ {
+		select {
+		case indexes <- started:
+		case <-sweepCtx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	sweep := &parsers.PingSweep{Total: len(targets), Hosts: hosts[:started]}
+	var stdout strings.Builder
+	for _, host := range sweep.Hosts {
+		if host.Reachable {
+			sweep.Reachable++
+		}
+		if host.Error != "" {
+			sweep.Failed++
+		}
+		stdout.WriteString(host.Summary() + "\n")
+	}
+
+	canceled := ctx.Err() != nil
+	timedOut := !canceled && sweepCtx.Err() != nil
+	result := &ToolResult{
+		Stdout:         stdout.String(),
+		Success:        !canceled && !timedOut && sweep.Failed == 0,
+		TimedOut:       timedOut,
+		Canceled:       canceled,
+		PartialResults: (canceled || timedOut) && len(sweep.Hosts) > 0,
+		Parsed:         sweep,
+	}
+	// A sweep has no exit code of its own, its summary counts the hosts
+	// that could not be pinged
+	summarize(result, sweep.Summary(), 0)
+	return result
+}
+
+// pingError describes why ping failed to run against a target
+func pingError(result *ToolResult) string {
+	if stderr := strings.TrimSpace(parsers.StripANSI(result.Stderr)); stderr != "" && result.StderrEncoding == "" {
+		return stderr
+	}
+	return fmt.Sprintf("ping exited with code %d", result.ReturnCode)
+}
+
+// pingTargets validates targets and expands CIDR ranges into hosts. It
+// reports whether the targets are to be swept rather than a single host.
+func pingTargets(list []string) (targets []string, sweep bool, err error) {
+	seen := make(map[string]bool)
+	for _, item := range list {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.Contains(item, "/") {
+			hosts, err := expandCIDR(item, MaxPingTargets-len(targets))
+			if err != nil {
+				return nil, false, err
+			}
+			for _, host := range hosts {
+				if !seen[host] {
+					seen[host] = true
+					targets = append(targets, host)
+				}
+			}
+			sweep = true
+			continue
+		}
+
+		// Sanitize target
+		target, err := security.SanitizeTarget(item)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid target: %v", err)
+		}
+		if err := security.ValidatePositionalArg("target", target); err != nil {
+			return nil, false, err
+		}
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+		if len(targets) > MaxPingTargets {
+			return nil, false, fmt.Errorf("too many targets (max: %d)", MaxPingTargets)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, false, fmt.Errorf("target parameter is required")
+	}
+	return targets, sweep || len(targets) > 1, nil
+}
+
+// expandCIDR returns the host addresses of an IP range, leaving out the
+// network and broadcast addresses of IPv4 ranges larger than /31
+func expandCIDR(cidr string, max int) ([]string, error) {
+	prefix, err := netip.ParsePrefix(cidr)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid CIDR range: %s", cidr)
+	}
+	prefix = prefix.Masked()
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits >= 31 || 1<<hostBits > max+2 {
+		return nil, fmt.Errorf("CIDR range %s is too large (max: %d hosts)", cidr, MaxPingTargets)
 	}
 
-	return &ToolResult{
-		Stdout:         result.Stdout,
-		Stderr:         result.Stderr,
-		Success:        result.Success,
-		ReturnCode:     result.ReturnCode,
-		TimedOut:       result.TimedOut,
-		PartialResults: result.PartialResults,
-	}, nil
+	var hosts []string
+	for addr := prefix.Addr(); addr.IsValid() && prefix.Contains(addr); addr = addr.Next() {
+		hosts = append(hosts, addr.String())
+	}
+	if prefix.Addr().Is4() && hostBits >= 2 {
+		// Network and broadcast address
+		hosts = hosts[1 : len(hosts)-1]
+	}
+	if len(hosts) > max {
+		return nil, fmt.Errorf("CIDR range %s is too large (max: %d hosts)", cidr, MaxPingTargets)
+	}
+	return hosts, nil
 }
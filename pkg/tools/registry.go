@@ -0,0 +1,298 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+// Runner executes a tool with JSON encoded arguments
+type Runner func(ctx context.Context, arguments json.RawMessage) (*ToolResult, error)
+
+// Definition describes a tool that can be executed by name. DefaultTimeout
+// applies when the caller does not pass timeout_seconds and MaxTimeout caps
+// what the caller may ask for; zero values fall back to the global timeout
+// and no cap respectively. Schema returns the JSON schema of the arguments.
+//
+// Title, ReadOnly, Destructive, Idempotent and OpenWorld describe the tool
+// to clients as in the MCP tool annotations: whether it leaves its
+// environment unchanged, may destroy something in it, can be repeated with
+// no further effect, and talks to hosts outside the server. Risk is the
+// server's own classification used to require confirmation.
+type Definition struct {
+	Name           string
+	Title          string
+	Description    string
+	DefaultTimeout time.Duration
+	MaxTimeout     time.Duration
+	Run            Runner
+	Schema         func() (*jsonschema.Schema, error)
+
+	// ReadOnly marks tools that only observe their targets. Active scanners
+	// like Nmap are not read-only, as the traffic they send can trip
+	// alarms, fill logs or upset fragile services.
+	ReadOnly    bool
+	Destructive bool
+	Idempotent  bool
+	OpenWorld   bool
+	Risk        Risk
+}
+
+// registry holds every tool that can be executed by name
+var registry = map[string]Definition{}
+
+func init() {
+	Register(Definition{
+		Name:           "nmap_scan",
+		Title:          "Nmap Port Scan",
+		Description:    "Execute an Nmap scan against a target",
+		DefaultTimeout: 30 * time.Minute,
+		MaxTimeout:     4 * time.Hour,
+		Run:            runner(NmapScan),
+		Schema:         InputSchema[NmapParams],
+		Idempotent:     true,
+		OpenWorld:      true,
+		Risk:           RiskMedium,
+	})
+	Register(Definition{
+		Name:           "gobuster_scan",
+		Title:          "Gobuster Content Discovery",
+		Description:    "Execute Gobuster to find directories, DNS subdomains, or virtual hosts",
+		DefaultTimeout: 30 * time.Minute,
+		MaxTimeout:     4 * time.Hour,
+		Run:            runner(GobusterScan),
+		Schema:         InputSchema[GobusterParams],
+		Idempotent:     true,
+		OpenWorld:      true,
+		Risk:           RiskMedium,
+	})
+	Register(Definition{
+		Name:           "dirb_scan",
+		Title:          "Dirb Web Content Scan",
+		Description:    "Execute Dirb web content scanner",
+		DefaultTimeout: 30 * time.Minute,
+		MaxTimeout:     4 * time.Hour,
+		Run:            runner(DirbScan),
+		Schema:         InputSchema[DirbParams],
+		Idempotent:     true,
+		OpenWorld:      true,
+		Risk:           RiskMedium,
+	})
+	Register(Definition{
+		Name:           "nikto_scan",
+		Title:          "Nikto Web Server Scan",
+		Description:    "Execute Nikto web server scanner",
+		DefaultTimeout: 30 * time.Minute,
+		MaxTimeout:     4 * time.Hour,
+		Run:            runner(NiktoScan),
+		Schema:         InputSchema[NiktoParams],
+		Idempotent:     true,
+		OpenWorld:      true,
+		Risk:           RiskMedium,
+	})
+	Register(Definition{
+		Name:           "sqlmap_scan",
+		Title:          "SQLmap SQL Injection",
+		Description:    "Execute SQLmap SQL injection scanner",
+		DefaultTimeout: 30 * time.Minute,
+		MaxTimeout:     4 * time.Hour,
+		Run:            runner(SqlmapScan),
+		Schema:         InputSchema[SqlmapParams],
+		Destructive:    true,
+		OpenWorld:      true,
+		Risk:           RiskHigh,
+	})
+	Register(Definition{
+		Name:           "sqlmap_resume",
+		Title:          "SQLmap Resume Session",
+		Description:    "Resume the SQLmap session of a previous sqlmap_scan job or run, e.g. with --dbs, --tables or --dump after an injection was found",
+		DefaultTimeout: 30 * time.Minute,
+		MaxTimeout:     4 * time.Hour,
+		Run:            runner(SqlmapResume),
+		Schema:         InputSchema[SqlmapResumeParams],
+		Destructive:    true,
+		OpenWorld:      true,
+		Risk:           RiskHigh,
+	})
+	Register(Definition{
+		Name:           "hydra_attack",
+		Title:          "Hydra Password Attack",
+		Description:    "Execute Hydra password cracking tool",
+		DefaultTimeout: time.Hour,
+		MaxTimeout:     12 * time.Hour,
+		Run:            runner(HydraAttack),
+		Schema:         InputSchema[HydraParams],
+		Destructive:    true,
+		OpenWorld:      true,
+		Risk:           RiskHigh,
+	})
+	Register(Definition{
+		Name:           "john_crack",
+		Title:          "John the Ripper Hash Cracking",
+		Description:    "Execute John the Ripper password cracker",
+		DefaultTimeout: 2 * time.Hour,
+		MaxTimeout:     24 * time.Hour,
+		Run:            runner(JohnCrack),
+		Schema:         InputSchema[JohnParams],
+		Idempotent:     true,
+		Risk:           RiskLow,
+	})
+	Register(Definition{
+		Name:           "wpscan_analyze",
+		Title:          "WPScan WordPress Scan",
+		Description:    "Execute WPScan WordPress vulnerability scanner, optionally keeping only vulnerabilities of min_severity or above",
+		DefaultTimeout: 20 * time.Minute,
+		MaxTimeout:     2 * time.Hour,
+		Run:            runner(WpscanAnalyze),
+		Schema:         InputSchema[WpscanParams],
+		Idempotent:     true,
+		OpenWorld:      true,
+		Risk:           RiskMedium,
+	})
+	Register(Definition{
+		Name:           "enum4linux_scan",
+		Title:          "Enum4linux SMB Enumeration",
+		Description:    "Execute Enum4linux Windows/Samba enumeration tool",
+		DefaultTimeout: 10 * time.Minute,
+		MaxTimeout:     time.Hour,
+		Run:            runner(Enum4linuxScan),
+		Schema:         InputSchema[Enum4linuxParams],
+		Idempotent:     true,
+		OpenWorld:      true,
+		Risk:           RiskMedium,
+	})
+	Register(Definition{
+		Name:           "ping",
+		Title:          "Ping",
+		Description:    "Ping a host, or sweep a list of targets or a CIDR range, reporting packet loss, round trip times, TTLs and reachability",
+		DefaultTimeout: time.Minute,
+		MaxTimeout:     10 * time.Minute,
+		Run:            runner(Ping),
+		Schema:         InputSchema[PingParams],
+		ReadOnly:       true,
+		Idempotent:     true,
+		OpenWorld:      true,
+		Risk:           RiskLow,
+	})
+	Register(Definition{
+		Name:           "nuclei_scan",
+		Title:          "Nuclei Vulnerability Scan",
+		Description:    "Execute Nuclei template-based vulnerability scanner",
+		DefaultTimeout: 30 * time.Minute,
+		MaxTimeout:     4 * time.Hour,
+		Run:            runner(NucleiScan),
+		Schema:         InputSchema[NucleiParams],
+		Idempotent:     true,
+		OpenWorld:      true,
+		Risk:           RiskMedium,
+	})
+	Register(Definition{
+		Name:           "sublist3r_scan",
+		Title:          "Sublist3r Subdomain Enumeration",
+		Description:    "Execute Sublist3r for subdomain enumeration, optionally resolving the subdomains and detecting wildcard DNS",
+		DefaultTimeout: 15 * time.Minute,
+		MaxTimeout:     time.Hour,
+		Run:            runner(Sublist3rScan),
+		Schema:         InputSchema[Sublist3rParams],
+		Idempotent:     true,
+		OpenWorld:      true,
+		Risk:           RiskMedium,
+	})
+	Register(Definition{
+		Name:           "metasploit_run",
+		Title:          "Metasploit Module Run",
+		Description:    "Run a Metasploit module with the given options",
+		DefaultTimeout: 30 * time.Minute,
+		MaxTimeout:     4 * time.Hour,
+		Run:            runner(MetasploitRun),
+		Schema:         InputSchema[MetasploitParams],
+		Destructive:    true,
+		OpenWorld:      true,
+		Risk:           RiskCritical,
+	})
+	Register(Definition{
+		Name:        "execute_command",
+		Title:       "Execute Command",
+		Description: "Execute an arbitrary command on the Kali server",
+		MaxTimeout:  2 * time.Hour,
+		Run:         runner(ExecuteGenericCommand),
+		Schema:      InputSchema[GenericCommandParams],
+		Destructive: true,
+		OpenWorld:   true,
+		Risk:        RiskCritical,
+	})
+}
+
+// Register adds a tool definition to the registry, replacing any tool with
+// the same name. Tools registered without a risk are classified critical.
+func Register(def Definition) {
+	if def.Risk == "" {
+		def.Risk = RiskCritical
+	}
+	registry[def.Name] = def
+}
+
+// Lookup returns the definition of the named tool
+func Lookup(name string) (Definition, bool) {
+	def, ok := registry[name]
+	return def, ok
+}
+
+// Definitions returns all registered tools sorted by name
+func Definitions() []Definition {
+	defs := make([]Definition, 0, len(registry))
+	for _, def := range registry {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// Timeout returns the timeout for a run of the tool that asked for seconds,
+// using the tool's default when seconds is not positive and clamping it to
+// the tool's maximum
+func (d Definition) Timeout(seconds int) time.Duration {
+	var timeout time.Duration
+	switch {
+	case seconds <= 0:
+		timeout = d.DefaultTimeout
+		if timeout == 0 {
+			timeout = executor.GlobalTimeout
+		}
+	case int64(seconds) > int64(math.MaxInt64/time.Second):
+		// Do not let the conversion overflow into a short timeout
+		timeout = math.MaxInt64
+	default:
+		timeout = time.Duration(seconds) * time.Second
+	}
+	if d.MaxTimeout > 0 && timeout > d.MaxTimeout {
+		timeout = d.MaxTimeout
+	}
+	return timeout
+}
+
+// Timeout returns the timeout for a run of the named tool that asked for
+// seconds. Unknown tools use the global timeout.
+func Timeout(name string, seconds int) time.Duration {
+	def, _ := Lookup(name)
+	return def.Timeout(seconds)
+}
+
+// runner adapts a typed tool function to a Runner
+func runner[T any](fn func(context.Context, T) (*ToolResult, error)) Runner {
+	return func(ctx context.Context, arguments json.RawMessage) (*ToolResult, error) {
+		var params T
+		if len(arguments) > 0 {
+			if err := json.Unmarshal(arguments, &params); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+		}
+		return fn(ctx, params)
+	}
+}
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
+)
+
+const (
+	// resolveWorkers is the number of names resolved in parallel
+	resolveWorkers = 16
+	// resolveTimeout bounds the lookups of a single name
+	resolveTimeout = 5 * time.Second
+)
+
+// resolveSubdomains resolves the A/AAAA and CNAME records of subs in place.
+// It first resolves a random name under domain: if that exists the domain
+// has a wildcard record, and names resolving to the same addresses are
+// flagged as wildcard matches. It returns the wildcard addresses, if any.
+func resolveSubdomains(ctx context.Context, domain string, subs []parsers.Subdomain) []string {
+	var wildcard parsers.Subdomain
+	if label, err := randomLabel(); err == nil {
+		wildcard.Subdomain = label + "." + domain
+		resolveSubdomain(ctx, &wildcard)
+	}
+	wildcardIPs := make(map[string]bool)
+	for _, ip := range wildcard.IPs {
+		wildcardIPs[ip] = true
+	}
+
+	names := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < resolveWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range names {
+				sub := &subs[i]
+				resolveSubdomain(ctx, sub)
+				sub.Wildcard = len(wildcardIPs) > 0 && len(sub.IPs) > 0 && allIn(sub.IPs, wildcardIPs)
+			}
+		}()
+	}
+	for i := range subs {
+		if ctx.Err() != nil {
+			break
+		}
+		names <- i
+	}
+	close(names)
+	wg.Wait()
+
+	return wildcard.IPs
+}
+
+// resolveSubdomain looks up the addresses and canonical name of sub
+func resolveSubdomain(ctx context.Context, sub *parsers.Subdomain) {
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, sub.Subdomain)
+	if err != nil {
+		return
+	}
+	sub.IPs = nil
+	for _, addr := range addrs {
+		sub.IPs = append(sub.IPs, addr.IP.String())
+	}
+	sort.Strings(sub.IPs)
+	if cname, err := net.DefaultResolver.LookupCNAME(ctx, sub.Subdomain); err == nil {
+		cname = strings.TrimSuffix(cname, ".")
+		if !strings.EqualFold(cname, sub.Subdomain) {
+			sub.CNAME = cname
+		}
+	}
+}
+
+// randomLabel returns a DNS label that is very unlikely to exist
+func randomLabel() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "wildcard-" + hex.EncodeToString(b), nil
+}
+
+// allIn reports whether every element of list is in set
+func allIn(list []string, set map[string]bool) bool {
+	for _, item := range list {
+		if !set[item] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Risk classifies what running a tool may do to its targets, so that
+// clients and the server can ask for confirmation before risky runs
+type Risk string
+
+// Risk levels, from least to most risky
+const (
+	// RiskLow tools only observe, like ping or passive subdomain enumeration
+	RiskLow Risk = "low"
+	// RiskMedium tools actively probe targets and are noisy, like port and
+	// vulnerability scanners, but do not change them
+	RiskMedium Risk = "medium"
+	// RiskHigh tools attack targets and may change them or lock accounts
+	// out, like password and SQL injection attacks
+	RiskHigh Risk = "high"
+	// RiskCritical tools can do anything, like running arbitrary commands
+	RiskCritical Risk = "critical"
+)
+
+// riskLevels lists the risk levels in increasing order
+var riskLevels = []Risk{RiskLow, RiskMedium, RiskHigh, RiskCritical}
+
+// ParseRisk parses a risk level
+func ParseRisk(s string) (Risk, error) {
+	if !slices.Contains(riskLevels, Risk(s)) {
+		return "", fmt.Errorf("invalid risk level %q, expected one of low, medium, high or critical", s)
+	}
+	return Risk(s), nil
+}
+
+// AtLeast reports whether r is as risky as other or more
+func (r Risk) AtLeast(other Risk) bool {
+	return slices.Index(riskLevels, r) >= slices.Index(riskLevels, other)
+}
+
+// ConfirmRisk is the risk level from which tool runs must be confirmed by
+// the caller. Empty means no run needs confirmation.
+var ConfirmRisk Risk
+
+// SetConfirmRisk sets the risk level from which tool runs must be confirmed
+func SetConfirmRisk(risk Risk) {
+	ConfirmRisk = risk
+}
+
+// NeedsConfirmation reports whether runs of the tool must be confirmed by
+// the caller under the ConfirmRisk policy
+func (d Definition) NeedsConfirmation() bool {
+	return ConfirmRisk != "" && d.Risk.AtLeast(ConfirmRisk)
+}
+
+// ConfirmationError is returned for runs of a tool that need confirmation
+// but were not confirmed
+type ConfirmationError struct {
+	Tool string
+	Risk Risk
+}
+
+func (e *ConfirmationError) Error() string {
+	return fmt.Sprintf("%s is a %s risk tool and must be confirmed before it runs", e.Tool, e.Risk)
+}
@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+// InputSchema returns the JSON schema of the tool parameters T. Fields
+// without omitempty are required and the jsonschema tag of a field is its
+// description. The enum, default and example tags add the allowed values,
+// the default and an example value of a field; enum values and the items
+// of list examples are separated by commas, maps take JSON.
+func InputSchema[T any]() (*jsonschema.Schema, error) {
+	schema, err := jsonschema.For[T]()
+	if err != nil {
+		return nil, err
+	}
+	t := reflect.TypeFor[T]()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		prop := schema.Properties[name]
+		if prop == nil {
+			continue
+		}
+		if tag, ok := field.Tag.Lookup("enum"); ok {
+			for _, s := range strings.Split(tag, ",") {
+				v, err := tagValue(field.Type, s)
+				if err != nil {
+					return nil, fmt.Errorf("enum of %s: %w", name, err)
+				}
+				prop.Enum = append(prop.Enum, v)
+			}
+		}
+		if tag, ok := field.Tag.Lookup("default"); ok {
+			v, err := tagValue(field.Type, tag)
+			if err != nil {
+				return nil, fmt.Errorf("default of %s: %w", name, err)
+			}
+			if prop.Default, err = json.Marshal(v); err != nil {
+				return nil, err
+			}
+		}
+		if tag, ok := field.Tag.Lookup("example"); ok {
+			v, err := tagValue(field.Type, tag)
+			if err != nil {
+				return nil, fmt.Errorf("example of %s: %w", name, err)
+			}
+			prop.Examples = []any{v}
+		}
+	}
+	return schema, nil
+}
+
+// tagValue converts the text of a struct tag to a value of type t
+func tagValue(t reflect.Type, s string) (any, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return s, nil
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		return n, err
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		return b, err
+	case reflect.Slice:
+		items := []any{}
+		for _, item := range strings.Split(s, ",") {
+			v, err := tagValue(t.Elem(), item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	default:
+		var v any
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+// OutputSchema returns the JSON schema of the tool output T. Times are
+// described as date-time strings and raw JSON fields as any value, which
+// is how they are encoded.
+func OutputSchema[T any]() (*jsonschema.Schema, error) {
+	schema, err := jsonschema.For[T]()
+	if err != nil {
+		return nil, err
+	}
+	fixEncodedTypes(schema, reflect.TypeFor[T](), map[reflect.Type]bool{})
+	return schema, nil
+}
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// fixEncodedTypes replaces the inferred schemas of the types in t that
+// encode themselves as JSON differently from their Go structure
+func fixEncodedTypes(schema *jsonschema.Schema, t reflect.Type, seen map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case schema == nil:
+		return
+	case t == timeType:
+		*schema = jsonschema.Schema{Type: "string", Format: "date-time"}
+		return
+	case t == rawMessageType:
+		*schema = jsonschema.Schema{}
+		return
+	case seen[t]:
+		return
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" {
+				name = field.Name
+			}
+			fixEncodedTypes(schema.Properties[name], field.Type, seen)
+		}
+	case reflect.Slice, reflect.Array:
+		fixEncodedTypes(schema.Items, t.Elem(), seen)
+	case reflect.Map:
+		fixEncodedTypes(schema.AdditionalProperties, t.Elem(), seen)
+	}
+}
@@ -1,43 +1,161 @@
 package tools
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
 )
 
+// sqlmapSessionFile stores the target of a sqlmap run in its run directory
+// so that the session can be resumed
+const sqlmapSessionFile = "sqlmap-session.json"
+
 // SqlmapParams represents parameters for SQLmap scan
 type SqlmapParams struct {
-	URL            string `json:"url"`
-	Data           string `json:"data"`
-	AdditionalArgs string `json:"additional_args"`
+	URL            string `json:"url" jsonschema:"Target URL including the parameters to test" example:"http://example.com/item.php?id=1"`
+	Data           string `json:"data,omitempty" jsonschema:"POST body to send and test" example:"username=admin&password=secret"`
+	AdditionalArgs string `json:"additional_args,omitempty" jsonschema:"Additional SQLmap arguments, --batch is always passed" example:"--level 3 --risk 2"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, defaults to the tool timeout and is capped by its maximum" example:"1800"`
+}
+
+// SqlmapResumeParams represents parameters for resuming a SQLmap session
+type SqlmapResumeParams struct {
+	JobID          string `json:"job_id,omitempty" jsonschema:"Background job that ran sqlmap_scan or sqlmap_resume, job_id or run_id is required"`
+	RunID          string `json:"run_id,omitempty" jsonschema:"Run ID of a previous sqlmap result, used instead of job_id"`
+	AdditionalArgs string `json:"additional_args,omitempty" jsonschema:"SQLmap arguments for the resumed session" example:"--dbs"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, defaults to the tool timeout and is capped by its maximum" example:"1800"`
+}
+
+// JobRunID returns the run ID of a finished background job. It is set by
+// the jobs package, which depends on this one.
+var JobRunID func(jobID string) (string, error)
+
+// sqlmapSession is the target and output directory of a sqlmap session
+type sqlmapSession struct {
+	URL       string `json:"url"`
+	Data      string `json:"data,omitempty"`
+	OutputDir string `json:"output_dir"`
 }
 
 // SqlmapScan executes SQLmap with the provided parameters
-func SqlmapScan(params SqlmapParams) (*ToolResult, error) {
+func SqlmapScan(ctx context.Context, params SqlmapParams) (*ToolResult, error) {
 	if params.URL == "" {
 		return nil, fmt.Errorf("URL parameter is required")
 	}
 
-	command := fmt.Sprintf("sqlmap -u %s --batch", params.URL)
-	if params.Data != "" {
-		command += fmt.Sprintf(" --data=\"%s\"", params.Data)
+	runID, dir, err := newRun()
+	if err != nil {
+		return nil, err
+	}
+	session := sqlmapSession{
+		URL:       params.URL,
+		Data:      params.Data,
+		OutputDir: filepath.Join(dir, "sqlmap"),
+	}
+	return runSqlmap(ctx, Timeout("sqlmap_scan", params.TimeoutSeconds), runID, dir, session, params.AdditionalArgs)
+}
+
+// SqlmapResume runs sqlmap again against the target of a previous run,
+// reusing its session so that detection is skipped and e.g. --dbs, --tables
+// or --dump can build on the injection found before
+func SqlmapResume(ctx context.Context, params SqlmapResumeParams) (*ToolResult, error) {
+	if params.RunID == "" && params.JobID != "" && JobRunID != nil {
+		runID, err := JobRunID(params.JobID)
+		if err != nil {
+			return nil, err
+		}
+		params.RunID = runID
+	}
+	if params.RunID == "" {
+		return nil, fmt.Errorf("job_id or run_id parameter is required")
+	}
+	if strings.ContainsAny(params.RunID, `/\`) || params.RunID == "." || params.RunID == ".." {
+		return nil, fmt.Errorf("invalid run ID: %q", params.RunID)
+	}
+
+	data, err := os.ReadFile(filepath.Join(executor.ArtifactDir, params.RunID, sqlmapSessionFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no sqlmap session found for run %s", params.RunID)
 	}
-	if params.AdditionalArgs != "" {
-		command += fmt.Sprintf(" %s", params.AdditionalArgs)
+	if err != nil {
+		return nil, err
+	}
+	var session sqlmapSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("invalid sqlmap session for run %s: %w", params.RunID, err)
 	}
 
-	result, err := executor.ExecuteCommand(command)
+	runID, dir, err := newRun()
 	if err != nil {
 		return nil, err
 	}
+	return runSqlmap(ctx, Timeout("sqlmap_resume", params.TimeoutSeconds), runID, dir, session, params.AdditionalArgs)
+}
 
-	return &ToolResult{
-		Stdout:         result.Stdout,
-		Stderr:         result.Stderr,
-		Success:        result.Success,
-		ReturnCode:     result.ReturnCode,
-		TimedOut:       result.TimedOut,
-		PartialResults: result.PartialResults,
-	}, nil
+// runSqlmap runs sqlmap against the session's target, records the session
+// in the run directory and parses the output directory afterwards
+func runSqlmap(ctx context.Context, timeout time.Duration, runID, dir string, session sqlmapSession, additionalArgs string) (*ToolResult, error) {
+	args := []string{"-u", session.URL, "--batch"}
+	if session.Data != "" {
+		args = append(args, "--data="+session.Data)
+	}
+	args, err := appendExtraArgs(args, additionalArgs)
+	if err != nil {
+		discardRun(runID)
+		return nil, fmt.Errorf("invalid additional_args: %w", err)
+	}
+	if outputDir := sqlmapOutputDir(args); outputDir != "" {
+		session.OutputDir = outputDir
+	} else {
+		args = append(args, "--output-dir", session.OutputDir)
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		discardRun(runID)
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, sqlmapSessionFile), data, 0600); err != nil {
+		discardRun(runID)
+		return nil, fmt.Errorf("failed to record sqlmap session: %w", err)
+	}
+
+	result, err := runToolInRun(ctx, timeout, runID, "sqlmap", args)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := parsers.ParseSqlmapOutputDir(session.OutputDir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Failed to parse sqlmap results: %v", err)
+		}
+		return result, nil
+	}
+	result.Parsed = report
+	summarize(result, report.Summary())
+	return result, nil
+}
+
+// sqlmapOutputDir returns the output directory already present in args, if any
+func sqlmapOutputDir(args []string) string {
+	for i, arg := range args {
+		switch {
+		case arg == "--output-dir" && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(arg, "--output-dir="):
+			return strings.TrimPrefix(arg, "--output-dir=")
+		}
+	}
+	return ""
 }
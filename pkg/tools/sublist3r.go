@@ -1,73 +1,103 @@
 package tools
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
-	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
 )
 
 // Sublist3rParams represents parameters for Sublist3r subdomain enumeration
 type Sublist3rParams struct {
-	Domain         string `json:"domain"`
-	BruteForce     bool   `json:"bruteforce"`
-	Ports          string `json:"ports"`
-	Threads        int    `json:"threads"`
-	Engines        string `json:"engines"`
-	Verbose        bool   `json:"verbose"`
-	AdditionalArgs string `json:"additional_args"`
+	Domain         string `json:"domain" jsonschema:"Domain to enumerate subdomains of" example:"example.com"`
+	BruteForce     bool   `json:"bruteforce,omitempty" jsonschema:"Also brute force subdomains with the subbrute module"`
+	Ports          string `json:"ports,omitempty" jsonschema:"Comma separated TCP ports to check on the found subdomains" example:"80,443"`
+	Threads        int    `json:"threads,omitempty" jsonschema:"Number of threads for brute forcing" default:"10"`
+	Engines        string `json:"engines,omitempty" jsonschema:"Comma separated search engines to use, all when empty" example:"google,bing,virustotal"`
+	Verbose        bool   `json:"verbose,omitempty" jsonschema:"Show the subdomains in real time"`
+	AdditionalArgs string `json:"additional_args,omitempty" jsonschema:"Additional Sublist3r arguments"`
+	Resolve        bool   `json:"resolve,omitempty" jsonschema:"Resolve the found subdomains and detect wildcard DNS"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, defaults to the tool timeout and is capped by its maximum" example:"900"`
 }
 
 // Sublist3rScan executes Sublist3r for subdomain enumeration
-func Sublist3rScan(params Sublist3rParams) (*ToolResult, error) {
+func Sublist3rScan(ctx context.Context, params Sublist3rParams) (*ToolResult, error) {
 	if params.Domain == "" {
 		return nil, fmt.Errorf("domain parameter is required")
 	}
 
-	// Build command
-	command := "sublist3r"
-	
 	// Add domain
-	command += fmt.Sprintf(" -d %s", params.Domain)
+	args := []string{"-d", params.Domain}
 
 	// Add optional parameters
 	if params.BruteForce {
-		command += " -b"
+		args = append(args, "-b")
 	}
 
 	if params.Ports != "" {
-		command += fmt.Sprintf(" -p %s", params.Ports)
+		args = append(args, "-p", params.Ports)
 	}
 
 	if params.Threads > 0 {
-		command += fmt.Sprintf(" -t %d", params.Threads)
+		args = append(args, "-t", strconv.Itoa(params.Threads))
 	} else {
 		// Default threads
-		command += " -t 10"
+		args = append(args, "-t", "10")
 	}
 
 	if params.Engines != "" {
-		command += fmt.Sprintf(" -e %s", params.Engines)
+		args = append(args, "-e", params.Engines)
 	}
 
 	if params.Verbose {
-		command += " -v"
+		args = append(args, "-v")
 	}
 
-	if params.AdditionalArgs != "" {
-		command += fmt.Sprintf(" %s", params.AdditionalArgs)
+	args, err := appendExtraArgs(args, params.AdditionalArgs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid additional_args: %w", err)
 	}
 
-	result, err := executor.ExecuteCommand(command)
+	runID, dir, err := newRun()
+	if err != nil {
+		return nil, err
+	}
+	result, err := runToolInRun(ctx, Timeout("sublist3r_scan", params.TimeoutSeconds), runID, "sublist3r", args)
 	if err != nil {
 		return nil, err
 	}
 
-	return &ToolResult{
-		Stdout:         result.Stdout,
-		Stderr:         result.Stderr,
-		Success:        result.Success,
-		ReturnCode:     result.ReturnCode,
-		TimedOut:       result.TimedOut,
-		PartialResults: result.PartialResults,
-	}, nil
+	report := &parsers.SubdomainReport{
+		Domain:     strings.ToLower(params.Domain),
+		Subdomains: parsers.ParseSublist3rOutput(params.Domain, fullStdout(result)),
+	}
+	if params.Resolve && ctx.Err() == nil {
+		report.WildcardIPs = resolveSubdomains(ctx, report.Domain, report.Subdomains)
+		report.WildcardDNS = len(report.WildcardIPs) > 0
+		report.Resolved = true
+	}
+	markDiscovered(&parsers.ContentDiscovery{Subdomains: report.Subdomains})
+
+	// Write the subdomains worth scanning further as a target list
+	var targets strings.Builder
+	for _, sub := range report.Subdomains {
+		if !report.Resolved || len(sub.IPs) > 0 && !sub.Wildcard {
+			targets.WriteString(sub.Subdomain + "\n")
+		}
+	}
+	targetList := filepath.Join(dir, "targets.txt")
+	if err := os.WriteFile(targetList, []byte(targets.String()), 0600); err != nil {
+		log.Printf("Failed to write target list: %v", err)
+	} else {
+		report.TargetList = targetList
+	}
+
+	result.Parsed = report
+	summarize(result, report.Summary())
+	return result, nil
 }
@@ -1,12 +1,174 @@
 package tools
 
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/helpers"
+)
+
 // ToolResult represents the result of a tool execution
 type ToolResult struct {
-	Stdout         string `json:"stdout"`
-	Stderr         string `json:"stderr"`
-	Success        bool   `json:"success"`
-	Error          string `json:"error,omitempty"`
-	ReturnCode     int    `json:"return_code"`
-	TimedOut       bool   `json:"timed_out"`
-	PartialResults bool   `json:"partial_results"`
+	Stdout         string  `json:"stdout"`
+	Stderr         string  `json:"stderr"`
+	StdoutEncoding string  `json:"stdout_encoding,omitempty"`
+	StderrEncoding string  `json:"stderr_encoding,omitempty"`
+	Success        bool    `json:"success"`
+	Error          string  `json:"error,omitempty"`
+	ReturnCode     int     `json:"return_code"`
+	TimedOut       bool    `json:"timed_out"`
+	Canceled       bool    `json:"canceled"`
+	Signal         string  `json:"signal,omitempty"`
+	PartialResults bool    `json:"partial_results"`
+	QueuePosition  int     `json:"queue_position,omitempty"`
+	QueueWait      float64 `json:"queue_wait_seconds,omitempty"`
+	RunID          string  `json:"run_id,omitempty"`
+	Truncated      bool    `json:"truncated,omitempty"`
+	StdoutFile     string  `json:"stdout_file,omitempty"`
+	StderrFile     string  `json:"stderr_file,omitempty"`
+	LimitExceeded  string  `json:"limit_exceeded,omitempty"`
+	// Summary is a short description of the parsed output, if the tool has a parser
+	Summary string `json:"summary,omitempty"`
+	// Parsed holds the tool's output as structured data, if the tool has a parser
+	Parsed interface{} `json:"parsed,omitempty"`
+}
+
+// newToolResult converts an executor result into a ToolResult. Output that
+// is not valid UTF-8 is base64 encoded so that it survives JSON encoding.
+func newToolResult(result *executor.Result) *ToolResult {
+	stdout, stdoutEncoding := executor.EncodeOutput([]byte(result.Stdout))
+	stderr, stderrEncoding := executor.EncodeOutput([]byte(result.Stderr))
+	return &ToolResult{
+		Stdout:         stdout,
+		Stderr:         stderr,
+		StdoutEncoding: stdoutEncoding,
+		StderrEncoding: stderrEncoding,
+		Success:        result.Success,
+		ReturnCode:     result.ReturnCode,
+		TimedOut:       result.TimedOut,
+		Canceled:       result.Canceled,
+		Signal:         result.Signal,
+		PartialResults: result.PartialResults,
+		QueuePosition:  result.QueuePosition,
+		QueueWait:      result.QueueWait,
+		RunID:          result.RunID,
+		Truncated:      result.Truncated,
+		StdoutFile:     result.StdoutFile,
+		StderrFile:     result.StderrFile,
+		LimitExceeded:  result.LimitExceeded,
+	}
+}
+
+// runTool executes binary with args without a shell and converts the result
+func runTool(ctx context.Context, timeout time.Duration, binary string, args []string) (*ToolResult, error) {
+	return runToolInRun(ctx, timeout, "", binary, args)
+}
+
+// runToolInRun is like runTool but uses the artifact directory of runID,
+// for tools that write files next to their output
+func runToolInRun(ctx context.Context, timeout time.Duration, runID string, binary string, args []string) (*ToolResult, error) {
+	ce := executor.NewArgsExecutor(binary, args, timeout)
+	ce.RunID = runID
+	result, err := ce.ExecuteContext(ctx)
+	if err != nil {
+		discardRun(runID)
+		return nil, err
+	}
+	return newToolResult(result), nil
+}
+
+// newRun creates a run ID and its artifact directory
+func newRun() (runID string, dir string, err error) {
+	runID, err = executor.NewRunID()
+	if err != nil {
+		return "", "", err
+	}
+	dir, err = executor.RunDir(runID)
+	if err != nil {
+		return "", "", err
+	}
+	return runID, dir, nil
+}
+
+// discardRun removes the artifact directory of a run that failed before it
+// could be recorded, such as one rejected by a full queue
+func discardRun(runID string) {
+	if runID == "" {
+		return
+	}
+	if err := os.RemoveAll(filepath.Join(executor.ArtifactDir, runID)); err != nil {
+		log.Printf("Failed to remove run %s: %v", runID, err)
+	}
+}
+
+// fullStdout returns the complete standard output of a run for parsing,
+// reading it back from the artifact file when it was truncated
+func fullStdout(result *ToolResult) string {
+	if result.StdoutFile != "" {
+		if data, err := os.ReadFile(result.StdoutFile); err == nil {
+			return string(data)
+		}
+	}
+	if result.StdoutEncoding == executor.EncodingBase64 {
+		if data, err := base64.StdEncoding.DecodeString(result.Stdout); err == nil {
+			return string(data)
+		}
+	}
+	return result.Stdout
+}
+
+// summarize sets the summary of a run. A run that did not finish normally
+// may have stopped before it found anything, so its summary says that the
+// results are incomplete rather than reporting a clean target. findings are
+// the non-zero exit codes a tool uses to report what it found, which do not
+// make the results incomplete.
+func summarize(result *ToolResult, summary string, findings ...int) {
+	var outcome string
+	switch {
+	case result.TimedOut:
+		outcome = "timed out"
+	case result.Canceled:
+		outcome = "was canceled"
+	case result.LimitExceeded != "":
+		outcome = fmt.Sprintf("exceeded its %s limit", result.LimitExceeded)
+	case !result.Success && !slices.Contains(findings, result.ReturnCode):
+		outcome = fmt.Sprintf("failed with exit code %d", result.ReturnCode)
+	}
+	if outcome != "" {
+		summary = fmt.Sprintf("%s, but the run %s and the results are incomplete", summary, outcome)
+	}
+	result.Summary = summary
+}
+
+// appendExtraArgs splits the user supplied additional arguments and appends them to args
+func appendExtraArgs(args []string, additionalArgs string) ([]string, error) {
+	if additionalArgs == "" {
+		return args, nil
+	}
+	extra, err := helpers.SplitArgs(additionalArgs)
+	if err != nil {
+		return nil, err
+	}
+	return append(args, extra...), nil
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context that makes tools run with it report their
+// progress in their output when they only do so on request, as Nmap does
+func WithProgress(ctx context.Context) context.Context {
+	return context.WithValue(ctx, progressKey{}, true)
+}
+
+// progressWanted reports whether ctx asks tools to report their progress
+func progressWanted(ctx context.Context) bool {
+	wanted, _ := ctx.Value(progressKey{}).(bool)
+	return wanted
 }
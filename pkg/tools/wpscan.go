@@ -1,39 +1,89 @@
 package tools
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
 
-	"github.com/ba0f3/MCP-Kali-Server/pkg/executor"
+	"github.com/ba0f3/MCP-Kali-Server/pkg/parsers"
 )
 
 // WpscanParams represents parameters for WPScan
 type WpscanParams struct {
-	URL            string `json:"url"`
-	AdditionalArgs string `json:"additional_args"`
+	URL            string `json:"url" jsonschema:"URL of the WordPress site" example:"http://example.com"`
+	AdditionalArgs string `json:"additional_args,omitempty" jsonschema:"Additional WPScan arguments" example:"--enumerate vp,u"`
+	MinSeverity    string `json:"min_severity,omitempty" jsonschema:"Keep only vulnerabilities of this severity or above, vulnerabilities without a score are always kept" enum:"critical,high,medium,low,info"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum run time in seconds, defaults to the tool timeout and is capped by its maximum" example:"1200"`
 }
 
 // WpscanAnalyze executes WPScan with the provided parameters
-func WpscanAnalyze(params WpscanParams) (*ToolResult, error) {
+func WpscanAnalyze(ctx context.Context, params WpscanParams) (*ToolResult, error) {
 	if params.URL == "" {
 		return nil, fmt.Errorf("URL parameter is required")
 	}
+	if params.MinSeverity != "" {
+		if err := parsers.ValidateSeverity(params.MinSeverity); err != nil {
+			return nil, err
+		}
+	}
 
-	command := fmt.Sprintf("wpscan --url %s", params.URL)
-	if params.AdditionalArgs != "" {
-		command += fmt.Sprintf(" %s", params.AdditionalArgs)
+	args, err := appendExtraArgs([]string{"--url", params.URL}, params.AdditionalArgs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid additional_args: %w", err)
 	}
 
-	result, err := executor.ExecuteCommand(command)
+	// Write the report as JSON to parse it, unless the user chose a format
+	jsonPath, format := wpscanOutput(args)
+	runID, dir, err := newRun()
 	if err != nil {
 		return nil, err
 	}
+	if format == "" {
+		format = "json"
+		args = append(args, "--format", "json")
+	}
+	if jsonPath == "" {
+		jsonPath = filepath.Join(dir, "wpscan.json")
+		args = append(args, "--output", jsonPath)
+	}
 
-	return &ToolResult{
-		Stdout:         result.Stdout,
-		Stderr:         result.Stderr,
-		Success:        result.Success,
-		ReturnCode:     result.ReturnCode,
-		TimedOut:       result.TimedOut,
-		PartialResults: result.PartialResults,
-	}, nil
+	result, err := runToolInRun(ctx, Timeout("wpscan_analyze", params.TimeoutSeconds), runID, "wpscan", args)
+	if err != nil {
+		return nil, err
+	}
+	if format != "json" {
+		return result, nil
+	}
+
+	report, err := parsers.ParseWPScanJSONFile(jsonPath)
+	if err != nil {
+		log.Printf("Failed to parse wpscan results: %v", err)
+		return result, nil
+	}
+	report.FilterSeverity(params.MinSeverity)
+	result.Parsed = report
+	summarize(result, report.Summary(), wpscanVulnerable)
+	return result, nil
+}
+
+// wpscanVulnerable is the exit code of wpscan when it found vulnerabilities
+const wpscanVulnerable = 5
+
+// wpscanOutput returns the output file and format already present in args, if any
+func wpscanOutput(args []string) (path string, format string) {
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !hasValue && i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch name {
+		case "-o", "--output":
+			path = value
+		case "-f", "--format":
+			format = value
+		}
+	}
+	return path, format
 }